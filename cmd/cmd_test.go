@@ -0,0 +1,158 @@
+package cmd
+
+import (
+    "context"
+    "io/ioutil"
+    "net/http"
+    "os"
+    "strings"
+    "testing"
+
+    "github.com/spf13/cobra"
+    "github.com/spf13/pflag"
+    "github.com/scruwys/s3go/internal/s3test"
+)
+
+// Fake S3 server that every test command talks to.
+var testServer *s3test.Server
+
+func TestMain(m *testing.M) {
+    testServer = s3test.NewServer()
+
+    // Anything addressed to AWS, e.g. bucket region lookups, is routed to the fake server.
+    http.DefaultTransport = testServer.Transport()
+
+    os.Setenv("AWS_ACCESS_KEY_ID", "AKIAS3GOTESTING")
+    os.Setenv("AWS_SECRET_ACCESS_KEY", "s3go-testing-secret")
+    os.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+    os.Setenv("AWS_CONFIG_FILE", os.DevNull)
+
+    // A custom CA bundle makes the SDK swap in its own transport, bypassing the fake server.
+    os.Unsetenv("AWS_CA_BUNDLE")
+
+    // Commands shouldn't install signal handlers for the test binary.
+    newCommandContext = context.Background
+
+    code := m.Run()
+
+    testServer.Close()
+    os.Exit(code)
+}
+
+// Runs s3go with the given arguments and returns everything it printed to stdout.
+func runCommand(t *testing.T, args ...string) string {
+    t.Helper()
+
+    output, code := runCommandWithExitCode(t, args...)
+
+    if code != 0 {
+        t.Fatalf("s3go %s exited with %d:\n%s", strings.Join(args, " "), code, output)
+    }
+
+    return output
+}
+
+// Like runCommand, but also returns the code the command tried to exit with.
+func runCommandWithExitCode(t *testing.T, args ...string) (string, int) {
+    t.Helper()
+
+    resetFlags(RootCmd)
+
+    code := 0
+    exit = func(c int) {
+        code = c
+    }
+
+    stdout := os.Stdout
+    reader, writer, err := os.Pipe()
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    os.Stdout = writer
+    outputCh := make(chan string)
+
+    go func() {
+        output, _ := ioutil.ReadAll(reader)
+        outputCh <- string(output)
+    }()
+
+    RootCmd.SetArgs(args)
+    err = RootCmd.Execute()
+
+    writer.Close()
+    os.Stdout = stdout
+    output := <-outputCh
+
+    if err != nil {
+        t.Fatalf("s3go %s: %v", strings.Join(args, " "), err)
+    }
+
+    return output, code
+}
+
+// Flags are bound to package level variables, so they have to be reset between runs.
+func resetFlags(command *cobra.Command) {
+    // --include and --exclude append to a shared list instead of holding a value of their own.
+    flagFilters = nil
+
+    reset := func(flag *pflag.Flag) {
+        if _, ok := flag.Value.(*filterFlag); !ok {
+            flag.Value.Set(flag.DefValue)
+        }
+        flag.Changed = false
+    }
+
+    command.PersistentFlags().VisitAll(reset)
+    command.Flags().VisitAll(reset)
+
+    for _, child := range command.Commands() {
+        resetFlags(child)
+    }
+}
+
+// Creates a directory with the given files in it, returning its path.
+func createTestDir(t *testing.T, files map[string]string) string {
+    t.Helper()
+
+    dir, err := ioutil.TempDir("", "s3go-cmd")
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    for name, body := range files {
+        path := dir + "/" + name
+
+        if err := os.MkdirAll(path[:strings.LastIndex(path, "/")], 0700); err != nil {
+            t.Fatal(err)
+        }
+
+        if err := ioutil.WriteFile(path, []byte(body), 0600); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    return dir
+}
+
+func assertKeys(t *testing.T, bucket string, want ...string) {
+    t.Helper()
+
+    got := testServer.Keys(bucket)
+
+    if strings.Join(got, ",") != strings.Join(want, ",") {
+        t.Errorf("got keys %v in %s, want %v", got, bucket, want)
+    }
+}
+
+func assertContains(t *testing.T, output string, want ...string) {
+    t.Helper()
+
+    for _, w := range want {
+        if !strings.Contains(output, w) {
+            t.Errorf("expected output to contain %q, got:\n%s", w, output)
+        }
+    }
+}
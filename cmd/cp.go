@@ -1,7 +1,14 @@
 package cmd
 
 import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+
     "github.com/spf13/cobra"
+    "github.com/scruwys/s3go/internal"
 )
 
 var copyCommand = &cobra.Command{
@@ -13,11 +20,181 @@ var copyCommand = &cobra.Command{
 
 
 func copyCommandHandler(cmd *cobra.Command, args []string) {
+    source, target := parseTransferUrls(args)
+
+    if source.IsStream() || target.IsStream() {
+        copyStreamCommandHandler(source, target)
+        return
+    }
+
+    if flagExpectedSize > 0 {
+        s3go.ExitWithError(1, errors.New("--expected-size can only be used when uploading from stdin."))
+    }
+
     transferCommandHandler(args, "copy", false)
 }
 
+// Handles "cp - s3://bucket/key", which uploads stdin, and "cp s3://bucket/key -", which writes the object to stdout.
+func copyStreamCommandHandler(source, target *s3go.S3Url) {
+    validateStreamUrls(source, target)
+    validateVersionIdFlag(source)
+
+    metadata, expires := parseMetadataFlags()
+    mimeTypes := loadMimeTypesFlag()
+
+    client := newClientForTransfer(source, target)
+    ctx := newCommandContext()
+
+    options := &s3go.MoveObjectOptions{
+        Target:             target,
+        Source:             source,
+        ACL:                flagACL,
+        ContentDisposition: flagContentDisposition,
+        ContentEncoding:    flagContentEncoding,
+        ContentLanguage:    flagContentLanguage,
+        ContentType:        flagContentType,
+        GuessMimeType:      !flagNoGuessMimeType,
+        MimeTypes:          mimeTypes,
+        CacheControl:       flagCacheControl,
+        StorageClass:       flagStorageClass,
+        Metadata:           metadata,
+        Tagging:            flagTagging,
+        Expires:            expires,
+        WebsiteRedirect:    flagWebsiteRedirect,
+        DryRun:             flagDryRun,
+        RequestPayer:       flagRequestPayer,
+        ExpectedSize:       flagExpectedSize,
+        Encryption:         encryptionFromFlags(source, target),
+    }
+
+    // We append this to output when we are doing a dry run.
+    dryRunPrefix := ""
+
+    if flagDryRun {
+        dryRunPrefix = "(dryrun) "
+    }
+
+    var item s3go.ObjectInfo
+    var logMessage string
+    var err error
+
+    if source.IsStream() {
+        item = streamObjectInfo()
+
+        options.Progress = newProgress(true)
+        options.Progress.AddTotal(flagExpectedSize)
+        options.Progress.ListingDone()
+        options.Progress.Start()
+
+        logMessage, err = client.UploadStream(ctx, os.Stdin, target.Prefix, options)
+    } else {
+        item = findStreamSource(ctx, client, source, options.Encryption.SourceCustomerKey)
+
+        // Stdout carries the object itself, so nothing else may be printed there.
+        flagQuiet = true
+
+        logMessage, err = client.DownloadStream(ctx, item, os.Stdout, options)
+    }
+
+    summary := collectResults(options.Progress, singleResult(newObjectResult(ctx, item, dryRunPrefix + "copy: " + logMessage, "copy failed: " + item.Url(), err)))
+
+    options.Progress.Stop()
+    exitWithSummary(ctx, summary)
+}
+
+// "-" can only stand in for one side of a single object copy to or from S3.
+func validateStreamUrls(source, target *s3go.S3Url) {
+    if source.IsStream() && target.IsStream() {
+        s3go.ExitWithError(1, errors.New("Only one of the source and destination can be -."))
+    }
+
+    if flagRecursive {
+        s3go.ExitWithError(1, errors.New("- can't be used with --recursive."))
+    }
+
+    if (source.IsStream() && target.IsLocal()) || (target.IsStream() && source.IsLocal()) {
+        s3go.ExitWithError(1, errors.New("- can only be copied to or from an S3 object."))
+    }
+
+    if target.IsStream() && flagExpectedSize > 0 {
+        s3go.ExitWithError(1, errors.New("--expected-size can only be used when uploading from stdin."))
+    }
+
+    if source.IsStream() && (target.Prefix == "" || strings.HasSuffix(target.Prefix, "/")) {
+        s3go.ExitWithError(1, errors.New("Uploading from stdin needs the full key of the object, e.g. s3://bucket/key."))
+    }
+}
+
+// Looks up the single object that is written to stdout.
+func findStreamSource(ctx context.Context, client *s3go.Client, source *s3go.S3Url, customerKey []byte) s3go.ObjectInfo {
+    objectCh, err := client.ListSourceObjects(ctx, &s3go.ListSourceObjectsInput{
+        SourceUrl:   source,
+        VersionId:   flagVersionId,
+        CustomerKey: customerKey,
+    })
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    var found s3go.ObjectInfo
+    ok := false
+
+    // A non-recursive listing can include other keys sharing the prefix, so only an exact match counts.
+    for object := range objectCh {
+        if object.Err != nil {
+            s3go.ExitWithError(1, object.Err)
+        }
+
+        if !ok && !object.IsPrefix && *object.Key == source.Prefix {
+            found, ok = object, true
+        }
+    }
+
+    if !ok {
+        s3go.ExitWithError(1, fmt.Errorf("%s does not exist.", source.ObjectUrl(source.Prefix)))
+    }
+
+    return found
+}
+
+// Stands for stdin in results.
+func streamObjectInfo() s3go.ObjectInfo {
+    key := s3go.STREAM_PATH
+    bucket := ""
+
+    return s3go.ObjectInfo{Key: &key, Bucket: &bucket}
+}
+
+// A closed channel holding just the one result.
+func singleResult(result s3go.ObjectResult) <-chan s3go.ObjectResult {
+    resultCh := make(chan s3go.ObjectResult, 1)
+    resultCh <- result
+    close(resultCh)
+
+    return resultCh
+}
+
 func init() {
     addTransferFlags(copyCommand)
 
+    copyCommand.Flags().BoolVar(
+        &flagRecursive,
+        "recursive",
+        false,
+        "Command is performed on all files or objects under the specified directory or prefix.")
+
+    copyCommand.Flags().StringVar(
+        &flagVersionId,
+        "version-id",
+        "",
+        "Copies a specific version of the source object instead of the current one.")
+
+    copyCommand.Flags().Int64Var(
+        &flagExpectedSize,
+        "expected-size",
+        0,
+        "The size in bytes of a stream uploaded from stdin. Needed for streams larger than 48 GiB so that the part size can be raised to stay under 10,000 parts.")
+
 	RootCmd.AddCommand(copyCommand)
 }
@@ -0,0 +1,537 @@
+package cmd
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestCopyUpload(t *testing.T) {
+    dir := createTestDir(t, map[string]string{"a.txt": "alpha", "nested/b.txt": "bravo"})
+    defer os.RemoveAll(dir)
+
+    testServer.CreateBucket("cp-upload")
+
+    output := runCommand(t, "cp", dir, "s3://cp-upload/backup/", "--recursive")
+
+    assertContains(t, output, "copy: "+dir+"/a.txt to s3://cp-upload/backup/a.txt")
+    assertKeys(t, "cp-upload", "backup/a.txt", "backup/nested/b.txt")
+
+    if body := string(testServer.GetObject("cp-upload", "backup/nested/b.txt").Body); body != "bravo" {
+        t.Errorf("got %s, want bravo", body)
+    }
+}
+
+func TestCopyDownload(t *testing.T) {
+    testServer.PutObject("cp-download", "data/report.csv", []byte("a,b,c"))
+
+    dir := createTestDir(t, nil)
+    defer os.RemoveAll(dir)
+
+    target := filepath.Join(dir, "report.csv")
+    output := runCommand(t, "cp", "s3://cp-download/data/report.csv", target)
+
+    assertContains(t, output, "copy: s3://cp-download/data/report.csv to "+target)
+
+    body, err := ioutil.ReadFile(target)
+    if err != nil || string(body) != "a,b,c" {
+        t.Errorf("got %q (%v), want a,b,c", body, err)
+    }
+}
+
+func TestCopyBetweenBuckets(t *testing.T) {
+    testServer.PutObject("cp-source", "logs/1.log", []byte("one"))
+    testServer.PutObject("cp-source", "logs/2.log", []byte("two"))
+    testServer.CreateBucket("cp-target")
+
+    runCommand(t, "cp", "s3://cp-source/logs/", "s3://cp-target/archive/", "--recursive", "--concurrency", "2")
+
+    assertKeys(t, "cp-source", "logs/1.log", "logs/2.log")
+    assertKeys(t, "cp-target", "archive/1.log", "archive/2.log")
+}
+
+func TestCopyKeysThatNeedEscaping(t *testing.T) {
+    testServer.PutObject("cp-escape", "odd/a?b.txt", []byte("question"))
+    testServer.PutObject("cp-escape", "odd/100%+1 #2.txt", []byte("percent"))
+    testServer.PutObject("cp-escape", "odd/a", []byte("prefix of a?b.txt"))
+    testServer.CreateBucket("cp-escape-target")
+
+    runCommand(t, "cp", "s3://cp-escape/odd/", "s3://cp-escape-target/", "--recursive")
+
+    for key, want := range map[string]string{"a?b.txt": "question", "100%+1 #2.txt": "percent", "a": "prefix of a?b.txt"} {
+        if copied := testServer.GetObject("cp-escape-target", key); copied == nil || string(copied.Body) != want {
+            t.Errorf("%s: expected a copy of the source object with the same key", key)
+        }
+    }
+}
+
+func TestCopyDryRun(t *testing.T) {
+    testServer.PutObject("cp-dryrun", "file.txt", []byte("unchanged"))
+    testServer.CreateBucket("cp-dryrun-target")
+
+    output := runCommand(t, "cp", "s3://cp-dryrun/file.txt", "s3://cp-dryrun-target/", "--dryrun")
+
+    assertContains(t, output, "(dryrun) copy: s3://cp-dryrun/file.txt to s3://cp-dryrun-target/file.txt")
+    assertKeys(t, "cp-dryrun-target")
+}
+
+func TestCopyAllFailed(t *testing.T) {
+    testServer.PutObject("cp-failed", "secret.txt", []byte("secret"))
+    testServer.DenyKey("cp-failed", "secret.txt")
+
+    dir := createTestDir(t, nil)
+    defer os.RemoveAll(dir)
+
+    output, code := runCommandWithExitCode(t, "cp", "s3://cp-failed/", dir, "--recursive")
+
+    if code != 2 {
+        t.Errorf("got exit code %d, want 2", code)
+    }
+
+    assertContains(t, output, "Completed: 0 succeeded, 1 failed, 0 skipped.")
+
+    // Failed downloads don't leave empty or partial files behind.
+    if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+        t.Errorf("got %d files in the destination, want none", len(files))
+    }
+}
+
+func TestCopyFailedListing(t *testing.T) {
+    testServer.PutObject("cp-unlistable", "a.txt", []byte("a"))
+    testServer.DenyListing("cp-unlistable")
+
+    dir := createTestDir(t, nil)
+    defer os.RemoveAll(dir)
+
+    output, code := runCommandWithExitCode(t, "cp", "s3://cp-unlistable/", dir, "--recursive")
+
+    if code != 2 {
+        t.Errorf("got exit code %d, want 2", code)
+    }
+
+    assertContains(t, output, "Completed: 0 succeeded, 1 failed, 0 skipped.")
+}
+
+func TestCopyVersion(t *testing.T) {
+    testServer.EnableVersioning("cp-version")
+    testServer.CreateBucket("cp-version-target")
+
+    first := testServer.PutObject("cp-version", "notes.txt", []byte("first draft"))
+    testServer.PutObject("cp-version", "notes.txt", []byte("second draft"))
+
+    runCommand(t, "cp", "s3://cp-version/notes.txt", "s3://cp-version-target/notes.txt", "--version-id", first.VersionId)
+
+    if body := string(testServer.GetObject("cp-version-target", "notes.txt").Body); body != "first draft" {
+        t.Errorf("got %s, want first draft", body)
+    }
+
+    dir := createTestDir(t, nil)
+    defer os.RemoveAll(dir)
+
+    target := filepath.Join(dir, "notes.txt")
+    runCommand(t, "cp", "s3://cp-version/notes.txt", target, "--version-id", first.VersionId)
+
+    body, err := ioutil.ReadFile(target)
+    if err != nil || string(body) != "first draft" {
+        t.Errorf("got %q (%v), want first draft", body, err)
+    }
+}
+
+func TestCopyMultipart(t *testing.T) {
+    // Anything over 1 KiB is too large to copy in one go.
+    testServer.MaxCopySize = 1024
+    defer func() { testServer.MaxCopySize = 0 }()
+
+    body := []byte(strings.Repeat("0123456789", 300))
+    source := testServer.PutObject("cp-multipart", "big.csv", body)
+    source.Header.Set("Content-Type", "text/csv")
+    source.Header.Set("Content-Disposition", "attachment")
+    source.Header.Set("X-Amz-Meta-Owner", "data-team")
+    source.Header.Set("X-Amz-Tagging", "team=data")
+    testServer.CreateBucket("cp-multipart-target")
+
+    runCommand(t, "cp", "s3://cp-multipart/big.csv", "s3://cp-multipart-target/big.csv",
+        "--multipart-threshold", "1024", "--multipart-chunksize", "1000", "--multipart-concurrency", "2", "--acl", "bucket-owner-full-control")
+
+    copied := testServer.GetObject("cp-multipart-target", "big.csv")
+
+    if copied == nil || string(copied.Body) != string(body) {
+        t.Fatalf("the copy doesn't match the source")
+    }
+
+    if !strings.HasSuffix(copied.ETag, "-3") {
+        t.Errorf("got ETag %s, want a multipart ETag with 3 parts", copied.ETag)
+    }
+
+    // Metadata, content headers and tags are kept like a single CopyObject would, and the ACL is applied.
+    for name, want := range map[string]string{
+        "Content-Type":        "text/csv",
+        "Content-Disposition": "attachment",
+        "X-Amz-Meta-Owner":    "data-team",
+        "X-Amz-Tagging":       "team=data",
+        "X-Amz-Acl":           "bucket-owner-full-control",
+    } {
+        if got := copied.Header.Get(name); got != want {
+            t.Errorf("got %s %q, want %q", name, got, want)
+        }
+    }
+
+    if pending := testServer.PendingUploads(); pending != 0 {
+        t.Errorf("%d multipart uploads were left behind", pending)
+    }
+}
+
+// Points the SDK at a credentials file with a "source" profile for another account's access key.
+func useSourceProfile(t *testing.T, accessKeyId string) func() {
+    t.Helper()
+
+    dir := createTestDir(t, map[string]string{
+        "credentials": "[source]\naws_access_key_id = " + accessKeyId + "\naws_secret_access_key = s3go-source-secret\n",
+    })
+
+    os.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+    return func() {
+        os.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+        os.RemoveAll(dir)
+    }
+}
+
+func TestCopyFromAnotherAccount(t *testing.T) {
+    defer useSourceProfile(t, "AKIAS3GOSOURCE")()
+
+    source := testServer.PutObject("cp-account-source", "report.csv", []byte("a,b,c"))
+    source.Header.Set("Content-Type", "text/csv")
+    source.Header.Set("Cache-Control", "no-cache")
+    source.Header.Set("Content-Disposition", "attachment")
+    source.Header.Set("Content-Encoding", "identity")
+    source.Header.Set("X-Amz-Meta-Owner", "data")
+    source.Header.Set("X-Amz-Tagging", "team=data")
+    testServer.SetBucketOwner("cp-account-source", "AKIAS3GOSOURCE")
+    testServer.CreateBucket("cp-account-target")
+
+    // The destination credentials can't read the source, so the server-side copy is denied and
+    // the object is streamed instead.
+    output := runCommand(t, "cp", "s3://cp-account-source/report.csv", "s3://cp-account-target/report.csv", "--source-profile", "source")
+
+    assertContains(t, output, "copy: s3://cp-account-source/report.csv to s3://cp-account-target/report.csv")
+
+    if copied := testServer.GetObject("cp-account-target", "report.csv"); copied == nil || string(copied.Body) != "a,b,c" {
+        t.Fatal("the copy doesn't match the source")
+    }
+
+    // The streamed copy keeps what a server-side copy would have.
+    assertHeaders(t, "cp-account-target", "report.csv", map[string]string{
+        "Content-Type":        "text/csv",
+        "Cache-Control":       "no-cache",
+        "Content-Disposition": "attachment",
+        "Content-Encoding":    "identity",
+        "X-Amz-Meta-Owner":    "data",
+        "X-Amz-Tagging":       "team=data",
+    })
+
+    // Unless REPLACE asks for the ones given on the command line.
+    runCommand(t, "cp", "s3://cp-account-source/report.csv", "s3://cp-account-target/replaced.csv", "--source-profile", "source",
+        "--metadata-directive", "REPLACE", "--content-type", "application/csv")
+
+    assertHeaders(t, "cp-account-target", "replaced.csv", map[string]string{
+        "Content-Type":     "application/csv",
+        "Cache-Control":    "",
+        "X-Amz-Meta-Owner": "",
+        "X-Amz-Tagging":    "team=data",
+    })
+}
+
+func TestCopyLargeObjectFromAnotherAccount(t *testing.T) {
+    defer useSourceProfile(t, "AKIAS3GOSOURCE")()
+
+    testServer.MaxCopySize = 1024
+    defer func() { testServer.MaxCopySize = 0 }()
+
+    body := strings.Repeat("0123456789", 300)
+    testServer.PutObject("cp-account-large", "big.csv", []byte(body))
+    testServer.SetBucketOwner("cp-account-large", "AKIAS3GOSOURCE")
+    testServer.CreateBucket("cp-account-large-target")
+
+    // The source is looked up with its own credentials, but the destination's can't copy its parts,
+    // so the object is streamed instead.
+    runCommand(t, "cp", "s3://cp-account-large/big.csv", "s3://cp-account-large-target/big.csv",
+        "--source-profile", "source", "--multipart-threshold", "1024")
+
+    if copied := testServer.GetObject("cp-account-large-target", "big.csv"); copied == nil || string(copied.Body) != body {
+        t.Errorf("the copy doesn't match the source")
+    }
+}
+
+func TestCopyFromAnotherEndpoint(t *testing.T) {
+    defer useSourceProfile(t, "AKIAS3GOMINIO")()
+
+    testServer.PutObject("cp-endpoint-source", "logs/1.log", []byte("one"))
+    testServer.PutObject("cp-endpoint-source", "logs/2.log", []byte(strings.Repeat("two", 1000)))
+    testServer.SetBucketOwner("cp-endpoint-source", "AKIAS3GOMINIO")
+    testServer.CreateBucket("cp-endpoint-target")
+
+    runCommand(t, "cp", "s3://cp-endpoint-source/logs/", "s3://cp-endpoint-target/archive/", "--recursive",
+        "--source-endpoint-url", "http://minio.internal:9000", "--source-profile", "source", "--source-region", "us-east-1")
+
+    assertKeys(t, "cp-endpoint-target", "archive/1.log", "archive/2.log")
+
+    if copied := testServer.GetObject("cp-endpoint-target", "archive/2.log"); string(copied.Body) != strings.Repeat("two", 1000) {
+        t.Errorf("the copy doesn't match the source")
+    }
+
+    // Downloads read from the source endpoint too.
+    dir := createTestDir(t, nil)
+    defer os.RemoveAll(dir)
+
+    runCommand(t, "cp", "s3://cp-endpoint-source/logs/1.log", dir + "/",
+        "--source-endpoint-url", "http://minio.internal:9000", "--source-profile", "source")
+
+    if body, err := ioutil.ReadFile(filepath.Join(dir, "1.log")); err != nil || string(body) != "one" {
+        t.Errorf("got %q (%v), want one", body, err)
+    }
+}
+
+func TestCopyFromStdin(t *testing.T) {
+    dir := createTestDir(t, map[string]string{"dump.sql": "CREATE TABLE t;"})
+    defer os.RemoveAll(dir)
+
+    stdin, err := os.Open(filepath.Join(dir, "dump.sql"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer stdin.Close()
+
+    original := os.Stdin
+    os.Stdin = stdin
+    defer func() { os.Stdin = original }()
+
+    testServer.CreateBucket("cp-stdin")
+
+    output := runCommand(t, "cp", "-", "s3://cp-stdin/dumps/dump.sql", "--expected-size", "15", "--content-type", "application/sql")
+
+    assertContains(t, output, "copy: - to s3://cp-stdin/dumps/dump.sql")
+
+    uploaded := testServer.GetObject("cp-stdin", "dumps/dump.sql")
+
+    if uploaded == nil || string(uploaded.Body) != "CREATE TABLE t;" {
+        t.Fatalf("the upload doesn't match stdin")
+    }
+
+    if got := uploaded.Header.Get("Content-Type"); got != "application/sql" {
+        t.Errorf("got Content-Type %q, want application/sql", got)
+    }
+}
+
+func TestCopyToStdout(t *testing.T) {
+    testServer.PutObject("cp-stdout", "data/events.json", []byte(`{"a":1}`))
+    testServer.PutObject("cp-stdout", "data/events.json.bak", []byte(`{"a":0}`))
+
+    // Only the object itself is written to stdout, so it can be piped into other tools.
+    output := runCommand(t, "cp", "s3://cp-stdout/data/events.json", "-")
+
+    if output != `{"a":1}` {
+        t.Errorf("got %q, want the object body", output)
+    }
+}
+
+func TestCopyResumeDownload(t *testing.T) {
+    object := testServer.PutObject("cp-resume", "backup.tar", []byte("0123456789abcdef"))
+
+    // An earlier run got half way, and one before it was interrupted on an older version of the object.
+    // The partial file holds different bytes than S3, so it shows which ones were downloaded again.
+    dir := createTestDir(t, map[string]string{
+        ".backup.tar." + object.ETag + ".s3go-partial": "ABCDEFGH",
+        ".backup.tar.0ld3tag.s3go-partial":             "stale",
+    })
+    defer os.RemoveAll(dir)
+
+    target := filepath.Join(dir, "backup.tar")
+    runCommand(t, "cp", "s3://cp-resume/backup.tar", target, "--resume")
+
+    if body, err := ioutil.ReadFile(target); err != nil || string(body) != "ABCDEFGH89abcdef" {
+        t.Errorf("got %q (%v), want only the missing half to be downloaded", body, err)
+    }
+
+    if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+        t.Errorf("got %d files, want the partial downloads to be gone", len(files))
+    }
+}
+
+func TestCopyWithKMSEncryption(t *testing.T) {
+    dir := createTestDir(t, map[string]string{"a.txt": "alpha"})
+    defer os.RemoveAll(dir)
+
+    testServer.CreateBucket("cp-kms")
+
+    runCommand(t, "cp", filepath.Join(dir, "a.txt"), "s3://cp-kms/a.txt",
+        "--sse", "aws:kms", "--sse-kms-key-id", "alias/compliance", "--sse-kms-context", `{"team":"data"}`)
+
+    header := testServer.GetObject("cp-kms", "a.txt").Header
+
+    for name, want := range map[string]string{
+        "X-Amz-Server-Side-Encryption":                "aws:kms",
+        "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": "alias/compliance",
+        "X-Amz-Server-Side-Encryption-Context":        "eyJ0ZWFtIjoiZGF0YSJ9",
+    } {
+        if got := header.Get(name); got != want {
+            t.Errorf("got %s %q, want %q", name, got, want)
+        }
+    }
+}
+
+func TestCopyWithCustomerKey(t *testing.T) {
+    dir := createTestDir(t, map[string]string{
+        "a.txt":   "alpha",
+        "key.bin": "0123456789abcdef0123456789abcdef",
+    })
+    defer os.RemoveAll(dir)
+
+    keyFlag := "file:" + filepath.Join(dir, "key.bin")
+    testServer.CreateBucket("cp-sse-c")
+    testServer.CreateBucket("cp-sse-c-target")
+
+    runCommand(t, "cp", filepath.Join(dir, "a.txt"), "s3://cp-sse-c/a.txt", "--sse-c", keyFlag)
+
+    if got := testServer.GetObject("cp-sse-c", "a.txt").Header.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm"); got != "AES256" {
+        t.Fatalf("got algorithm %q, want the object to be encrypted with the customer key", got)
+    }
+
+    // Downloads decrypt the object with the same key.
+    target := filepath.Join(dir, "downloaded.txt")
+    runCommand(t, "cp", "s3://cp-sse-c/a.txt", target, "--sse-c", keyFlag)
+
+    if body, err := ioutil.ReadFile(target); err != nil || string(body) != "alpha" {
+        t.Errorf("got %q (%v), want alpha", body, err)
+    }
+
+    // Copies decrypt the source with its key and can encrypt the copy differently.
+    runCommand(t, "cp", "s3://cp-sse-c/a.txt", "s3://cp-sse-c-target/a.txt", "--sse-c-copy-source", keyFlag, "--sse", "AES256")
+
+    header := testServer.GetObject("cp-sse-c-target", "a.txt").Header
+
+    if header.Get("X-Amz-Server-Side-Encryption") != "AES256" || header.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm") != "" {
+        t.Errorf("got %v, want the copy encrypted with SSE-S3 only", header)
+    }
+}
+
+func TestCopyWithObjectMetadata(t *testing.T) {
+    dir := createTestDir(t, map[string]string{"index.html": "<html></html>"})
+    defer os.RemoveAll(dir)
+
+    testServer.CreateBucket("cp-metadata")
+
+    runCommand(t, "cp", filepath.Join(dir, "index.html"), "s3://cp-metadata/index.html",
+        "--storage-class", "STANDARD_IA",
+        "--metadata", "owner=data,build=42",
+        "--tagging", "team=data&env=prod",
+        "--cache-control", "max-age=3600",
+        "--expires", "2030-01-02T03:04:05Z",
+        "--website-redirect", "/new/index.html")
+
+    header := testServer.GetObject("cp-metadata", "index.html").Header
+
+    for name, want := range map[string]string{
+        "X-Amz-Storage-Class":             "STANDARD_IA",
+        "X-Amz-Meta-Owner":                "data",
+        "X-Amz-Meta-Build":                "42",
+        "X-Amz-Tagging":                   "team=data&env=prod",
+        "Cache-Control":                   "max-age=3600",
+        "Expires":                         "Wed, 02 Jan 2030 03:04:05 GMT",
+        "X-Amz-Website-Redirect-Location": "/new/index.html",
+    } {
+        if got := header.Get(name); got != want {
+            t.Errorf("got %s %q, want %q", name, got, want)
+        }
+    }
+}
+
+func TestCopyMetadataDirective(t *testing.T) {
+    source := testServer.PutObject("cp-directive", "report.csv", []byte("a,b,c"))
+    source.Header.Set("Content-Type", "text/csv")
+    source.Header.Set("X-Amz-Meta-Owner", "data")
+    source.Header.Set("X-Amz-Tagging", "team=data")
+    testServer.CreateBucket("cp-directive-target")
+
+    // By default the copy keeps the source's headers, metadata and tags.
+    runCommand(t, "cp", "s3://cp-directive/report.csv", "s3://cp-directive-target/copied.csv", "--storage-class", "GLACIER")
+
+    header := testServer.GetObject("cp-directive-target", "copied.csv").Header
+
+    for name, want := range map[string]string{
+        "Content-Type":        "text/csv",
+        "X-Amz-Meta-Owner":    "data",
+        "X-Amz-Tagging":       "team=data",
+        "X-Amz-Storage-Class": "GLACIER",
+    } {
+        if got := header.Get(name); got != want {
+            t.Errorf("got %s %q, want %q", name, got, want)
+        }
+    }
+
+    // REPLACE swaps them for the ones given on the command line.
+    runCommand(t, "cp", "s3://cp-directive/report.csv", "s3://cp-directive-target/replaced.csv",
+        "--metadata-directive", "REPLACE", "--content-type", "application/csv", "--metadata", "owner=finance",
+        "--tagging", "team=finance")
+
+    header = testServer.GetObject("cp-directive-target", "replaced.csv").Header
+
+    for name, want := range map[string]string{
+        "Content-Type":     "application/csv",
+        "X-Amz-Meta-Owner": "finance",
+        "X-Amz-Tagging":    "team=finance",
+    } {
+        if got := header.Get(name); got != want {
+            t.Errorf("got %s %q, want %q", name, got, want)
+        }
+    }
+}
+
+func TestCopyGuessesContentType(t *testing.T) {
+    dir := createTestDir(t, map[string]string{
+        "site/index.html": "<html></html>",
+        "site/README.md":  "# Site",
+        "site/LICENSE":    "MIT License",
+        "mime.types":      "text/markdown md\n",
+    })
+    defer os.RemoveAll(dir)
+
+    testServer.CreateBucket("cp-mime")
+
+    runCommand(t, "cp", filepath.Join(dir, "site"), "s3://cp-mime/guessed/", "--recursive", "--mime-types", filepath.Join(dir, "mime.types"))
+    runCommand(t, "cp", filepath.Join(dir, "site"), "s3://cp-mime/explicit/", "--recursive", "--content-type", "text/plain")
+    runCommand(t, "cp", filepath.Join(dir, "site"), "s3://cp-mime/unguessed/", "--recursive", "--no-guess-mime-type")
+    runCommand(t, "cp", filepath.Join(dir, "site"), "s3://cp-mime/sniffed/", "--recursive", "--guess-mime-type-from-content")
+
+    for key, want := range map[string]string{
+        "guessed/index.html":   "text/html; charset=utf-8",
+        "guessed/README.md":    "text/markdown",
+        "guessed/LICENSE":      "binary/octet-stream",
+        "explicit/index.html":  "text/plain",
+        "unguessed/index.html": "binary/octet-stream",
+        "sniffed/LICENSE":      "text/plain; charset=utf-8",
+    } {
+        if got := testServer.GetObject("cp-mime", key).Header.Get("Content-Type"); got != want {
+            t.Errorf("got %s Content-Type %q, want %q", key, got, want)
+        }
+    }
+}
+
+func TestCopyUploadWithFilters(t *testing.T) {
+    dir := createTestDir(t, map[string]string{
+        "a.csv":         "a",
+        "b.json":        "b",
+        "nested/c.csv":  "c",
+        "scratch/d.csv": "d",
+    })
+    defer os.RemoveAll(dir)
+
+    testServer.CreateBucket("cp-filters")
+
+    // The same rules as the aws cli: matched relative to the source directory, later rules winning.
+    runCommand(t, "cp", dir, "s3://cp-filters/", "--recursive", "--exclude", "*", "--include", "*.csv", "--exclude", "scratch/*")
+
+    assertKeys(t, "cp-filters", "a.csv", "nested/c.csv")
+}
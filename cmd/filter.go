@@ -0,0 +1,87 @@
+package cmd
+
+import (
+    "context"
+    "strings"
+
+    "github.com/spf13/cobra"
+    "github.com/scruwys/s3go/internal"
+)
+
+// A repeatable --include or --exclude flag. Both append to the same list, so the rules keep the
+// order they were given in.
+type filterFlag struct {
+    exclude bool
+}
+
+func(f *filterFlag) Set(pattern string) error {
+    flagFilters = append(flagFilters, s3go.FilterRule{Exclude: f.exclude, Pattern: pattern})
+    return nil
+}
+
+func(f *filterFlag) String() string {
+    patterns := []string{}
+
+    for _, rule := range flagFilters {
+        if rule.Exclude == f.exclude {
+            patterns = append(patterns, rule.Pattern)
+        }
+    }
+
+    return strings.Join(patterns, ",")
+}
+
+func(f *filterFlag) Type() string {
+    return "stringArray"
+}
+
+// The --include and --exclude rules, in order.
+func filtersFromFlags() s3go.Filters {
+    return s3go.Filters{Rules: flagFilters, Regex: flagFilterRegex}
+}
+
+// Without --recursive an S3 URL names a single object, but its key is listed as a prefix, which
+// also finds keys that merely start with it, such as "report.csv.bak". Only the exact key is kept.
+func exactObjects(ctx context.Context, objectCh <-chan s3go.ObjectInfo, uri *s3go.S3Url) <-chan s3go.ObjectInfo {
+    if flagRecursive || uri.IsLocal() {
+        return objectCh
+    }
+
+    outputCh := make(chan s3go.ObjectInfo)
+
+    go func() {
+        defer close(outputCh)
+
+        for object := range objectCh {
+            if object.Err == nil && (object.IsPrefix || *object.Key != uri.Prefix) {
+                continue
+            }
+
+            select {
+                case outputCh <- object:
+                case <-ctx.Done():
+                    return
+            }
+        }
+    }()
+
+    return outputCh
+}
+
+func addFilterFlags(command *cobra.Command) {
+    command.Flags().Var(
+        &filterFlag{exclude: false},
+        "include",
+        "Don't exclude files or objects in the command that match the specified pattern. Can be repeated; later --include and --exclude rules take precedence.")
+
+    command.Flags().Var(
+        &filterFlag{exclude: true},
+        "exclude",
+        "Exclude all files or objects from the command that match the specified pattern. Can be repeated; later --include and --exclude rules take precedence.")
+
+    command.Flags().BoolVar(
+        &flagFilterRegex,
+        "filter-regex",
+        false,
+        "Treat --include and --exclude patterns as regular expressions matched against the full key or path, instead of globs relative to the source.")
+}
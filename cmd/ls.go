@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"strings"
 	"path/filepath"
 
@@ -27,10 +28,17 @@ func listCommandHandler(cmd *cobra.Command, args []string) {
     objectCt := 0
     objectSz := *new(int64)
 
-    objectCh, err := client.ListObjectsV2(&s3go.ListObjectsV2Input{
+    listObjects := client.ListObjectsV2
+
+    if flagVersions {
+        listObjects = client.ListObjectVersions
+    }
+
+    objectCh, err := listObjects(context.Background(), &s3go.ListObjectsV2Input{
         Bucket:    uri.Bucket,
         Prefix:    uri.Prefix,
         Recursive: flagRecursive,
+        Directory: true,
     })
 
     if err != nil {
@@ -38,6 +46,10 @@ func listCommandHandler(cmd *cobra.Command, args []string) {
     }
 
     for object := range objectCh {
+    	if object.Err != nil {
+    		s3go.ExitWithError(1, object.Err)
+    	}
+
     	if object.IsPrefix {
     		prefix := *object.Key
 
@@ -57,7 +69,16 @@ func listCommandHandler(cmd *cobra.Command, args []string) {
     		ok = filepath.Base(*object.Key)
     	}
 
-	    s3go.Echo("%s %11s %s", ts, sz, ok)
+	    if !flagVersions {
+	        s3go.Echo("%s %11s %s", ts, sz, ok)
+	    } else if object.IsDeleteMarker {
+	        s3go.Echo("%s %11s %s %s (delete marker)", ts, "", ok, versionLabel(object))
+	        continue
+	    } else if object.IsLatest {
+	        s3go.Echo("%s %11s %s %s (latest)", ts, sz, ok, versionLabel(object))
+	    } else {
+	        s3go.Echo("%s %11s %s %s", ts, sz, ok, versionLabel(object))
+	    }
 
 	    objectSz += *object.Size
 	    objectCt += 1
@@ -69,6 +90,14 @@ func listCommandHandler(cmd *cobra.Command, args []string) {
     }
 }
 
+// Objects written before versioning was enabled have no version id of their own.
+func versionLabel(object s3go.ObjectInfo) string {
+    if object.VersionId == nil || *object.VersionId == "" {
+        return "null"
+    }
+    return *object.VersionId
+}
+
 func init() {
 	listCommand.Flags().BoolVar(
 		&flagRecursive,
@@ -76,6 +105,12 @@ func init() {
 		false,
 		"Command is performed on all files or objects under the specified directory or prefix.")
 
+	listCommand.Flags().BoolVar(
+		&flagVersions,
+		"versions",
+		false,
+		"Lists every version of each object, including delete markers.")
+
 	listCommand.Flags().BoolVar(
 		&flagHumanReadable,
 		"human-readable",
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestListPrefixes(t *testing.T) {
+    testServer.PutObject("ls-bucket", "2020/01/a.txt", []byte("a"))
+    testServer.PutObject("ls-bucket", "2020/02/b.txt", []byte("bb"))
+    testServer.PutObject("ls-bucket", "2020/readme.md", []byte("ccc"))
+
+    output := runCommand(t, "ls", "s3://ls-bucket/2020/")
+
+    assertContains(t, output, "PRE 01/", "PRE 02/", "readme.md")
+}
+
+func TestListRecursiveSummary(t *testing.T) {
+    testServer.PutObject("ls-summary", "a/1.txt", []byte("12345"))
+    testServer.PutObject("ls-summary", "b/2.txt", []byte("67890"))
+
+    output := runCommand(t, "ls", "s3://ls-summary/", "--recursive", "--summarize")
+
+    assertContains(t, output, "a/1.txt", "b/2.txt", "Total Objects: 2", "Total Size: 10")
+}
+
+func TestListWithEndpointUrl(t *testing.T) {
+    testServer.PutObject("ls-endpoint", "a.txt", []byte("a"))
+
+    output := runCommand(t, "ls", "s3://ls-endpoint/", "--endpoint-url", testServer.URL)
+
+    assertContains(t, output, "a.txt")
+}
+
+func TestListWithProvider(t *testing.T) {
+    testServer.PutObject("ls-provider", "a.txt", []byte("a"))
+
+    for _, provider := range []string{"minio", "ceph", "generic"} {
+        output := runCommand(t, "ls", "s3://ls-provider/", "--endpoint-url", testServer.URL, "--provider", provider)
+
+        assertContains(t, output, "a.txt")
+    }
+}
+
+func TestListVersions(t *testing.T) {
+    testServer.EnableVersioning("ls-versions")
+
+    first := testServer.PutObject("ls-versions", "a.txt", []byte("one"))
+    second := testServer.PutObject("ls-versions", "a.txt", []byte("two"))
+    testServer.PutObject("ls-versions", "b.txt", []byte("b"))
+    testServer.DeleteObject("ls-versions", "b.txt")
+
+    output := runCommand(t, "ls", "s3://ls-versions/", "--versions", "--summarize")
+
+    assertContains(t, output,
+        "a.txt "+second.VersionId+" (latest)",
+        "a.txt "+first.VersionId+"\n",
+        "b.txt "+testServer.Versions("ls-versions", "b.txt")[1].VersionId+" (delete marker)",
+        "Total Objects: 3")
+
+    // Versions of a key are listed newest first.
+    if strings.Index(output, second.VersionId) > strings.Index(output, first.VersionId) {
+        t.Errorf("versions are not listed newest first:\n%s", output)
+    }
+}
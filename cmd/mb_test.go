@@ -0,0 +1,15 @@
+package cmd
+
+import (
+    "testing"
+)
+
+func TestMakeBucket(t *testing.T) {
+    output := runCommand(t, "mb", "s3://mb-new-bucket")
+
+    assertContains(t, output, "make_bucket: s3://mb-new-bucket")
+
+    if !testServer.HasBucket("mb-new-bucket") {
+        t.Errorf("the bucket should have been created")
+    }
+}
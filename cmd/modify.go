@@ -0,0 +1,306 @@
+package cmd
+
+import (
+    "context"
+    "errors"
+
+    "github.com/spf13/cobra"
+    "github.com/scruwys/s3go/internal"
+)
+
+var modifyCommand = &cobra.Command{
+	Use:   "modify",
+	Short: "Changes the storage class, metadata, headers, tags or encryption of S3 object(s) in place.",
+    Args:  cobra.ExactArgs(1),
+	Run:   modifyCommandHandler,
+}
+
+func modifyCommandHandler(cmd *cobra.Command, args []string) {
+    uri, err := s3go.ParseUrl(args[0])
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    if uri.IsLocal() {
+        s3go.ExitWithError(1, errors.New("modify only works on S3 objects."))
+    }
+
+    encryption := encryptionFromFlags(uri, uri)
+    metadata, expires := parseMetadataFlags()
+
+    if !hasModifyFlags() {
+        s3go.ExitWithError(1, errors.New("Nothing to modify. Give at least one of --storage-class, --metadata, --tagging, --acl, --sse or a content header."))
+    }
+
+    client := newClientWithRegionFromBucket(uri.Bucket)
+    ctx := newCommandContext()
+
+    objectCh, err := client.ListObjectsV2(ctx, &s3go.ListObjectsV2Input{
+        Bucket:      uri.Bucket,
+        Prefix:      uri.Prefix,
+        Recursive:   flagRecursive,
+        Filters:     filtersFromFlags(),
+        CustomerKey: encryption.SourceCustomerKey,
+    })
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    progress := newProgress(true)
+    progress.Start()
+
+    options := &s3go.MoveObjectOptions{
+        ACL:                flagACL,
+        ContentDisposition: flagContentDisposition,
+        ContentEncoding:    flagContentEncoding,
+        ContentLanguage:    flagContentLanguage,
+        ContentType:        flagContentType,
+        CacheControl:       flagCacheControl,
+        StorageClass:       flagStorageClass,
+        Metadata:           metadata,
+        Tagging:            flagTagging,
+        Expires:            expires,
+        WebsiteRedirect:    flagWebsiteRedirect,
+        Encryption:         encryption,
+        RequestPayer:       flagRequestPayer,
+        DryRun:             flagDryRun,
+        Progress:           progress,
+    }
+
+    workerInput := &modifyCommandWorkerInput{progress.Track(ctx, exactObjects(ctx, objectCh, uri)), ctx, options}
+    workers := make([]<-chan s3go.ObjectResult, flagConcurrency)
+
+    for i := 0; i < flagConcurrency; i++ {
+        workers[i] = modifyCommandWorker(client, workerInput)
+    }
+
+    summary := collectResults(progress, workers...)
+
+    progress.Stop()
+    exitWithSummary(ctx, summary)
+}
+
+// Whether any of the flags that say what to change were given. The customer key for objects
+// encrypted with SSE-C only says how to read them.
+func hasModifyFlags() bool {
+    for _, value := range []string{
+        flagACL, flagCacheControl, flagContentDisposition, flagContentEncoding, flagContentLanguage,
+        flagContentType, flagExpires, flagMetadata, flagSSE, flagSSEC, flagStorageClass, flagTagging,
+        flagWebsiteRedirect,
+    } {
+        if value != "" {
+            return true
+        }
+    }
+    return false
+}
+
+type modifyCommandWorkerInput struct {
+    // Objects to modify
+    objectCh <-chan s3go.ObjectInfo
+
+    // Cancelled when the command is interrupted
+    ctx context.Context
+
+    // What to change. Everything else is kept.
+    options *s3go.MoveObjectOptions
+}
+
+func modifyCommandWorker(client *s3go.Client, input *modifyCommandWorkerInput) <-chan s3go.ObjectResult {
+    resultCh := make(chan s3go.ObjectResult)
+
+    // We append this to output when we are doing a dry run.
+    dryRunPrefix := ""
+
+    if flagDryRun {
+        dryRunPrefix = "(dryrun) "
+    }
+
+    go func() {
+        defer close(resultCh)
+        for item := range input.objectCh {
+            // Nothing new is started once the command has been interrupted.
+            if input.ctx.Err() != nil {
+                return
+            }
+
+            if item.Err != nil {
+                resultCh <- newListingFailedResult(item)
+                continue
+            }
+
+            if item.IsPrefix {
+                continue
+            }
+
+            logMessage, err := client.ModifyObject(input.ctx, item, input.options)
+
+            resultCh <- newObjectResult(input.ctx, item, dryRunPrefix + "modify: " + logMessage, "modify failed: " + item.Url(), err)
+        }
+    }()
+    return resultCh
+}
+
+func init() {
+    modifyCommand.Flags().BoolVar(
+        &flagRecursive,
+        "recursive",
+        false,
+        "Command is performed on all objects under the specified prefix.")
+
+    modifyCommand.Flags().BoolVar(
+        &flagDryRun,
+        "dryrun",
+        false,
+        "Displays the operations that would be performed using the specified command without actually running them.")
+
+    modifyCommand.Flags().BoolVar(
+        &flagQuiet,
+        "quiet",
+        false,
+        "Does not display the operations performed from the specified command.")
+
+    modifyCommand.Flags().BoolVar(
+        &flagOnlyShowErrors,
+        "only-show-errors",
+        false,
+        "Only errors and warnings are displayed. All other output is suppressed.")
+
+    addFilterFlags(modifyCommand)
+
+    modifyCommand.Flags().StringVar(
+        &flagRequestPayer,
+        "request-payer",
+        "",
+        "Confirms that the requester knows that she or he will be charged for the request.")
+
+    modifyCommand.Flags().StringVar(
+        &flagStorageClass,
+        "storage-class",
+        "",
+        "The new storage class, e.g. STANDARD_IA or GLACIER_IR.")
+
+    modifyCommand.Flags().StringVar(
+        &flagMetadata,
+        "metadata",
+        "",
+        "User-defined metadata to add, as comma separated key=value pairs. Existing keys are overwritten, other keys are kept.")
+
+    modifyCommand.Flags().StringVar(
+        &flagTagging,
+        "tagging",
+        "",
+        "Tags to replace the existing ones with, as URL encoded key=value pairs joined by & (e.g., team=data&env=prod).")
+
+    modifyCommand.Flags().StringVar(
+        &flagACL,
+        "acl",
+        "",
+        "Replaces the ACL of the object with a canned ACL. Otherwise its current grants are kept.")
+
+    modifyCommand.Flags().StringVar(
+        &flagContentDisposition,
+        "content-disposition",
+        "",
+        "Specifies presentational information for the object.")
+
+    modifyCommand.Flags().StringVar(
+        &flagContentEncoding,
+        "content-encoding",
+        "",
+        "Specifies what content encodings have been applied to the object.")
+
+    modifyCommand.Flags().StringVar(
+        &flagContentLanguage,
+        "content-language",
+        "",
+        "The language the content is in.")
+
+    modifyCommand.Flags().StringVar(
+        &flagContentType,
+        "content-type",
+        "",
+        "The new content type of the object.")
+
+    modifyCommand.Flags().StringVar(
+        &flagCacheControl,
+        "cache-control",
+        "",
+        "Specifies caching behavior along the request/reply chain.")
+
+    modifyCommand.Flags().StringVar(
+        &flagExpires,
+        "expires",
+        "",
+        "The date and time at which the object is no longer cacheable, as an RFC 3339 timestamp (e.g., 2020-10-01T12:00:00Z).")
+
+    modifyCommand.Flags().StringVar(
+        &flagWebsiteRedirect,
+        "website-redirect",
+        "",
+        "Redirects requests for the object to another object in the same bucket or to an external URL, if the bucket is configured as a website.")
+
+    modifyCommand.Flags().StringVar(
+        &flagSSE,
+        "sse",
+        "",
+        "Re-encrypts objects with SSE-S3 (AES256) or SSE-KMS (aws:kms).")
+
+    modifyCommand.Flags().StringVar(
+        &flagSSEKMSKeyId,
+        "sse-kms-key-id",
+        "",
+        "The KMS key to encrypt objects with when using --sse aws:kms. Defaults to the AWS managed key.")
+
+    modifyCommand.Flags().StringVar(
+        &flagSSEKMSContext,
+        "sse-kms-context",
+        "",
+        "Encryption context for --sse aws:kms, as a JSON object (e.g., {\"team\":\"data\"}).")
+
+    modifyCommand.Flags().StringVar(
+        &flagSSEC,
+        "sse-c",
+        "",
+        "Customer provided key to re-encrypt objects with, as file:<path> or env:<name>.")
+
+    modifyCommand.Flags().StringVar(
+        &flagSSECCopySource,
+        "sse-c-copy-source",
+        "",
+        "Customer provided key the objects are currently encrypted with, as file:<path> or env:<name>. They stay encrypted with it unless --sse or --sse-c is given.")
+
+    modifyCommand.Flags().Int64Var(
+        &flagPartSize,
+        "multipart-chunksize",
+        0,
+        "The size in bytes of each part when objects are copied in parts.")
+
+    modifyCommand.Flags().Int64Var(
+        &flagMultipartThreshold,
+        "multipart-threshold",
+        0,
+        "Objects larger than this many bytes are copied in parts. Defaults to, and can't exceed, 5 GiB.")
+
+    modifyCommand.Flags().IntVar(
+        &flagMultipartConcurrency,
+        "multipart-concurrency",
+        0,
+        "Number of parts of each object that are copied in parallel. Defaults to 5.")
+
+    modifyCommand.Flags().BoolVar(
+        &flagNoProgress,
+        "no-progress",
+        false,
+        "Progress is not displayed.")
+
+    modifyCommand.Flags().IntVar(
+        &flagConcurrency,
+        "concurrency",
+        1,
+        "Number of concurrent workers (e.g., goroutines) to spin up.")
+
+	RootCmd.AddCommand(modifyCommand)
+}
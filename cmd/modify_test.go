@@ -0,0 +1,147 @@
+package cmd
+
+import (
+    "encoding/base64"
+    "reflect"
+    "strings"
+    "testing"
+
+    "github.com/scruwys/s3go/internal/s3test"
+)
+
+// Puts an object with the headers, metadata, tags and encryption that modify should keep.
+func putModifyObject(t *testing.T, bucket, key string, body []byte) {
+    t.Helper()
+
+    object := testServer.PutObject(bucket, key, body)
+    object.Header.Set("Content-Type", "text/csv")
+    object.Header.Set("Cache-Control", "no-cache")
+    object.Header.Set("X-Amz-Meta-Owner", "data")
+    object.Header.Set("X-Amz-Tagging", "team=data")
+    object.Header.Set("X-Amz-Server-Side-Encryption", "AES256")
+}
+
+func assertHeaders(t *testing.T, bucket, key string, want map[string]string) {
+    t.Helper()
+
+    header := testServer.GetObject(bucket, key).Header
+
+    for name, value := range want {
+        if got := header.Get(name); got != value {
+            t.Errorf("%s: got %s %q, want %q", key, name, got, value)
+        }
+    }
+}
+
+func TestModify(t *testing.T) {
+    putModifyObject(t, "modify-bucket", "data/a.csv", []byte("a,b,c"))
+    putModifyObject(t, "modify-bucket", "data/nested/b.csv", []byte("d,e,f"))
+    putModifyObject(t, "modify-bucket", "other/c.csv", []byte("g,h,i"))
+
+    output := runCommand(t, "modify", "s3://modify-bucket/data/", "--recursive",
+        "--storage-class", "GLACIER_IR", "--metadata", "build=42")
+
+    assertContains(t, output, "modify: s3://modify-bucket/data/a.csv", "modify: s3://modify-bucket/data/nested/b.csv", "Completed: 2 succeeded")
+
+    for _, key := range []string{"data/a.csv", "data/nested/b.csv"} {
+        assertHeaders(t, "modify-bucket", key, map[string]string{
+            "X-Amz-Storage-Class":          "GLACIER_IR",
+            "X-Amz-Meta-Build":             "42",
+            "X-Amz-Meta-Owner":             "data",
+            "Content-Type":                 "text/csv",
+            "Cache-Control":                "no-cache",
+            "X-Amz-Tagging":                "team=data",
+            "X-Amz-Server-Side-Encryption": "AES256",
+        })
+    }
+
+    assertHeaders(t, "modify-bucket", "other/c.csv", map[string]string{"X-Amz-Storage-Class": ""})
+
+    if body := string(testServer.GetObject("modify-bucket", "data/a.csv").Body); body != "a,b,c" {
+        t.Errorf("got %s, want a,b,c", body)
+    }
+
+    // Changing one header keeps the storage class set above.
+    runCommand(t, "modify", "s3://modify-bucket/data/a.csv", "--content-type", "application/csv", "--tagging", "team=finance")
+
+    assertHeaders(t, "modify-bucket", "data/a.csv", map[string]string{
+        "Content-Type":        "application/csv",
+        "X-Amz-Storage-Class": "GLACIER_IR",
+        "X-Amz-Tagging":       "team=finance",
+        "X-Amz-Meta-Build":    "42",
+    })
+}
+
+func TestModifyMultipart(t *testing.T) {
+    testServer.MaxCopySize = 1024
+    defer func() { testServer.MaxCopySize = 0 }()
+
+    body := []byte(strings.Repeat("0123456789", 300))
+    putModifyObject(t, "modify-multipart", "big.csv", body)
+
+    runCommand(t, "modify", "s3://modify-multipart/big.csv", "--storage-class", "STANDARD_IA",
+        "--multipart-threshold", "1024", "--multipart-chunksize", "1000")
+
+    object := testServer.GetObject("modify-multipart", "big.csv")
+
+    if string(object.Body) != string(body) || !strings.HasSuffix(object.ETag, "-3") {
+        t.Fatalf("got ETag %s, want the object copied over itself in 3 parts", object.ETag)
+    }
+
+    assertHeaders(t, "modify-multipart", "big.csv", map[string]string{
+        "X-Amz-Storage-Class":          "STANDARD_IA",
+        "X-Amz-Meta-Owner":             "data",
+        "Content-Type":                 "text/csv",
+        "X-Amz-Tagging":                "team=data",
+        "X-Amz-Server-Side-Encryption": "AES256",
+    })
+}
+
+func TestModifySingleObject(t *testing.T) {
+    putModifyObject(t, "modify-single", "report.csv", []byte("a,b,c"))
+    putModifyObject(t, "modify-single", "report.csv.bak", []byte("a,b,c"))
+
+    output := runCommand(t, "modify", "s3://modify-single/report.csv", "--storage-class", "STANDARD_IA")
+
+    assertContains(t, output, "modify: s3://modify-single/report.csv", "Completed: 1 succeeded")
+    assertHeaders(t, "modify-single", "report.csv", map[string]string{"X-Amz-Storage-Class": "STANDARD_IA"})
+    assertHeaders(t, "modify-single", "report.csv.bak", map[string]string{"X-Amz-Storage-Class": ""})
+}
+
+func TestModifyKeepsAcl(t *testing.T) {
+    putModifyObject(t, "modify-acl", "public.csv", []byte("a,b,c"))
+    testServer.GetObject("modify-acl", "public.csv").Header.Set("X-Amz-Acl", "public-read")
+
+    runCommand(t, "modify", "s3://modify-acl/public.csv", "--storage-class", "STANDARD_IA")
+
+    want := []s3test.Grant{
+        {Grantee: s3test.OwnerId, Permission: "FULL_CONTROL"},
+        {Grantee: "http://acs.amazonaws.com/groups/global/AllUsers", Permission: "READ"},
+    }
+
+    if got := testServer.GetObject("modify-acl", "public.csv").Grants(); !reflect.DeepEqual(got, want) {
+        t.Errorf("got grants %v, want %v", got, want)
+    }
+
+    // --acl replaces the grants instead.
+    runCommand(t, "modify", "s3://modify-acl/public.csv", "--acl", "private")
+
+    if got := testServer.GetObject("modify-acl", "public.csv").Grants(); len(got) != 1 {
+        t.Errorf("got grants %v, want only the owner's", got)
+    }
+}
+
+func TestModifyKMSEncryptionContext(t *testing.T) {
+    object := testServer.PutObject("modify-kms", "secret.csv", []byte("a,b,c"))
+    object.Header.Set("X-Amz-Server-Side-Encryption", "aws:kms")
+    object.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", "arn:aws:kms:us-east-1:123456789012:key/abc")
+
+    // S3 doesn't hand back the encryption context, so it has to be given again, and keeps the key.
+    runCommand(t, "modify", "s3://modify-kms/secret.csv", "--sse", "aws:kms", "--sse-kms-context", `{"team":"data"}`)
+
+    assertHeaders(t, "modify-kms", "secret.csv", map[string]string{
+        "X-Amz-Server-Side-Encryption":                "aws:kms",
+        "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": "arn:aws:kms:us-east-1:123456789012:key/abc",
+        "X-Amz-Server-Side-Encryption-Context":        base64.StdEncoding.EncodeToString([]byte(`{"team":"data"}`)),
+    })
+}
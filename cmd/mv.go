@@ -18,5 +18,11 @@ func moveCommandHandler(cmd *cobra.Command, args []string) {
 func init() {
     addTransferFlags(moveCommand)
 
+    moveCommand.Flags().BoolVar(
+        &flagRecursive,
+        "recursive",
+        false,
+        "Command is performed on all files or objects under the specified directory or prefix.")
+
 	RootCmd.AddCommand(moveCommand)
 }
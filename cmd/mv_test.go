@@ -0,0 +1,64 @@
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestMoveUpload(t *testing.T) {
+    dir := createTestDir(t, map[string]string{"a.txt": "alpha"})
+    defer os.RemoveAll(dir)
+
+    testServer.CreateBucket("mv-upload")
+
+    output := runCommand(t, "mv", filepath.Join(dir, "a.txt"), "s3://mv-upload/a.txt")
+
+    assertContains(t, output, "move: "+filepath.Join(dir, "a.txt")+" to s3://mv-upload/a.txt")
+    assertKeys(t, "mv-upload", "a.txt")
+
+    if _, err := os.Stat(filepath.Join(dir, "a.txt")); !os.IsNotExist(err) {
+        t.Errorf("the local file should have been removed")
+    }
+}
+
+func TestMoveBetweenBuckets(t *testing.T) {
+    testServer.PutObject("mv-source", "data/1.json", []byte("{}"))
+    testServer.PutObject("mv-source", "data/2.json", []byte("[]"))
+    testServer.CreateBucket("mv-target")
+
+    runCommand(t, "mv", "s3://mv-source/data/", "s3://mv-target/", "--recursive")
+
+    assertKeys(t, "mv-source")
+    assertKeys(t, "mv-target", "1.json", "2.json")
+}
+
+func TestMoveSingleObject(t *testing.T) {
+    testServer.PutObject("mv-single", "report.csv", []byte("a,b,c"))
+    testServer.PutObject("mv-single", "report.csv.bak", []byte("a,b"))
+
+    runCommand(t, "mv", "s3://mv-single/report.csv", "s3://mv-single/archive/report.csv")
+
+    assertKeys(t, "mv-single", "archive/report.csv", "report.csv.bak")
+}
+
+func TestMoveUnchangedStillRemovesSource(t *testing.T) {
+    dir := createTestDir(t, map[string]string{"a.txt": "alpha"})
+    defer os.RemoveAll(dir)
+
+    testServer.PutObject("mv-unchanged", "a.txt", []byte("alpha"))
+
+    // The destination already matches, so nothing is uploaded, but it's still a move.
+    output := runCommand(t, "mv", filepath.Join(dir, "a.txt"), "s3://mv-unchanged/a.txt", "--size-only")
+
+    assertContains(t, output, "move: "+filepath.Join(dir, "a.txt")+" to s3://mv-unchanged/a.txt")
+    assertKeys(t, "mv-unchanged", "a.txt")
+
+    if _, err := os.Stat(filepath.Join(dir, "a.txt")); !os.IsNotExist(err) {
+        t.Errorf("the local file should have been removed")
+    }
+
+    // Unless the destination is the source itself.
+    runCommand(t, "mv", "s3://mv-unchanged/a.txt", "s3://mv-unchanged/a.txt", "--size-only")
+    assertKeys(t, "mv-unchanged", "a.txt")
+}
@@ -2,6 +2,8 @@ package cmd
 
 import (
     "fmt"
+    "net/http"
+    "sort"
 
     "github.com/spf13/cobra"
     "github.com/scruwys/s3go/internal"
@@ -21,15 +23,38 @@ func presignCommandHandler(cmd *cobra.Command, args []string) {
         s3go.ExitWithError(1, err)
     }
 
+    customerKey := loadCustomerKeyFlag("--sse-c", flagSSEC)
     client := newClientWithPersistentFlags()
 
-    urlStr, err := client.Presign(uri.Bucket, uri.Prefix, flagExpiresIn)
+    urlStr, header, err := client.Presign(uri.Bucket, uri.Prefix, flagVersionId, flagExpiresIn, customerKey)
 
     if err != nil {
         s3go.ExitWithError(1, err)
     }
 
     fmt.Println(urlStr)
+
+    // Only stdout is meant for the URL, so it can still be captured by scripts.
+    if len(customerKey) > 0 {
+        s3go.EchoError("Requests for this URL must send these headers:")
+
+        for _, name := range sortedHeaderNames(header) {
+            s3go.EchoError("%s: %s", name, header.Get(name))
+        }
+    }
+}
+
+// The names of the headers, sorted so that the output is stable.
+func sortedHeaderNames(header http.Header) []string {
+    names := make([]string, 0, len(header))
+
+    for name := range header {
+        names = append(names, name)
+    }
+
+    sort.Strings(names)
+
+    return names
 }
 
 func init() {
@@ -39,5 +64,17 @@ func init() {
         3600,
         "Number of seconds until the pre-signed URL expires.")
 
+    presignCommand.Flags().StringVar(
+        &flagVersionId,
+        "version-id",
+        "",
+        "Sign a URL for a specific version of the object.")
+
+    presignCommand.Flags().StringVar(
+        &flagSSEC,
+        "sse-c",
+        "",
+        "The customer key the object is encrypted with, as file:<path> or env:<name>. The headers that carry it are printed to stderr.")
+
     RootCmd.AddCommand(presignCommand)
 }
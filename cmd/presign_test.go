@@ -0,0 +1,68 @@
+package cmd
+
+import (
+    "net/url"
+    "strings"
+    "testing"
+)
+
+func TestPresign(t *testing.T) {
+    testServer.PutObject("presign-bucket", "report.pdf", []byte("%PDF"))
+
+    output := strings.TrimSpace(runCommand(t, "presign", "s3://presign-bucket/report.pdf", "--expires-in", "600"))
+
+    presigned, err := url.Parse(output)
+    if err != nil {
+        t.Fatalf("%s is not a valid URL: %v", output, err)
+    }
+
+    if !strings.Contains(presigned.Host+presigned.Path, "presign-bucket") || !strings.HasSuffix(presigned.Path, "/report.pdf") {
+        t.Errorf("%s does not point at the object", output)
+    }
+
+    query := presigned.Query()
+
+    if query.Get("X-Amz-Expires") != "600" || query.Get("X-Amz-Signature") == "" {
+        t.Errorf("%s is not signed for 600 seconds", output)
+    }
+}
+
+func TestPresignWithSignatureV2(t *testing.T) {
+    testServer.PutObject("presign-v2", "report.pdf", []byte("%PDF"))
+
+    output := strings.TrimSpace(runCommand(t, "presign", "s3://presign-v2/report.pdf", "--endpoint-url", testServer.URL, "--provider", "ceph", "--signature-version", "v2"))
+
+    presigned, err := url.Parse(output)
+    if err != nil {
+        t.Fatalf("%s is not a valid URL: %v", output, err)
+    }
+
+    // Path-style addressing keeps the bucket out of the host name.
+    if presigned.Path != "/presign-v2/report.pdf" {
+        t.Errorf("%s is not a path-style URL", output)
+    }
+
+    query := presigned.Query()
+
+    if query.Get("AWSAccessKeyId") == "" || query.Get("Expires") == "" || query.Get("Signature") == "" {
+        t.Errorf("%s is not signed with signature version 2", output)
+    }
+}
+
+func TestPresignVersion(t *testing.T) {
+    testServer.EnableVersioning("presign-version")
+
+    first := testServer.PutObject("presign-version", "report.pdf", []byte("%PDF-1"))
+    testServer.PutObject("presign-version", "report.pdf", []byte("%PDF-2"))
+
+    output := strings.TrimSpace(runCommand(t, "presign", "s3://presign-version/report.pdf", "--version-id", first.VersionId))
+
+    presigned, err := url.Parse(output)
+    if err != nil {
+        t.Fatalf("%s is not a valid URL: %v", output, err)
+    }
+
+    if presigned.Query().Get("versionId") != first.VersionId {
+        t.Errorf("%s is not signed for version %s", output, first.VersionId)
+    }
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+    "context"
 
     "github.com/spf13/cobra"
     "github.com/scruwys/s3go/internal"
@@ -21,12 +22,55 @@ func removeBucketCommandHandler(cmd *cobra.Command, args []string) {
     }
 
     client := newClientWithRegionFromBucket(uri.Bucket)
+    ctx := newCommandContext()
+    summary := &s3go.ResultSummary{}
 
-    if err = client.RemoveBucket(uri.Bucket, flagForce); err != nil {
+    if flagForce {
+        summary = emptyBucket(ctx, client, uri.Bucket)
+
+        // The bucket can't be deleted while anything is left in it.
+        if summary.Failed > 0 || ctx.Err() != nil {
+            exitWithSummary(ctx, summary)
+            return
+        }
+    }
+
+    if err = client.RemoveBucket(ctx, uri.Bucket); err != nil {
         s3go.ExitWithError(1, err)
     }
 
     s3go.Echo("remove_bucket: %s://%s", uri.Scheme, uri.Bucket)
+    exitWithSummary(ctx, summary)
+}
+
+// Aborts incomplete multipart uploads, then deletes every object version and delete marker in the bucket.
+func emptyBucket(ctx context.Context, client *s3go.Client, bucketName string) *s3go.ResultSummary {
+    aborted, err := client.AbortMultipartUploads(ctx, bucketName)
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    if aborted > 0 && !flagQuiet {
+        s3go.Echo("abort: %d incomplete multipart upload(s) in s3://%s", aborted, bucketName)
+    }
+
+    objectCh, err := client.ListObjectVersions(ctx, &s3go.ListObjectsV2Input{
+        Bucket:    bucketName,
+        Recursive: true,
+    })
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    progress := newProgress(false)
+    progress.Start()
+
+    summary := runRemoveWorkers(ctx, client, progress, progress.Track(ctx, objectCh))
+
+    progress.Stop()
+    return summary
 }
 
 func init() {
@@ -34,7 +78,25 @@ func init() {
 		&flagForce,
 		"force",
 		false,
-		"Deletes all objects in the bucket including the bucket itself. Does not delete versions of objects.")
+		"Deletes all objects in the bucket, including every version, delete marker and incomplete multipart upload, and then the bucket itself.")
+
+	removeBucketCommand.Flags().BoolVar(
+		&flagQuiet,
+		"quiet",
+		false,
+		"Does not display the operations performed from the specified command.")
+
+	removeBucketCommand.Flags().BoolVar(
+		&flagNoProgress,
+		"no-progress",
+		false,
+		"Progress is not displayed.")
+
+	removeBucketCommand.Flags().IntVar(
+		&flagConcurrency,
+		"concurrency",
+		1,
+		"Number of concurrent workers (e.g., goroutines) to spin up.")
 
 	RootCmd.AddCommand(removeBucketCommand)
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+    "testing"
+)
+
+func TestRemoveBucket(t *testing.T) {
+    testServer.CreateBucket("rb-empty-bucket")
+
+    output := runCommand(t, "rb", "s3://rb-empty-bucket")
+
+    assertContains(t, output, "remove_bucket: s3://rb-empty-bucket")
+
+    if testServer.HasBucket("rb-empty-bucket") {
+        t.Errorf("the bucket should have been removed")
+    }
+}
+
+func TestRemoveBucketForce(t *testing.T) {
+    testServer.EnableVersioning("rb-force")
+
+    testServer.PutObject("rb-force", "a.txt", []byte("one"))
+    testServer.PutObject("rb-force", "a.txt", []byte("two"))
+    testServer.PutObject("rb-force", "logs/b.txt", []byte("b"))
+    testServer.DeleteObject("rb-force", "logs/b.txt")
+    testServer.CreateMultipartUpload("rb-force", "big.bin")
+
+    output := runCommand(t, "rb", "s3://rb-force", "--force")
+
+    assertContains(t, output,
+        "abort: 1 incomplete multipart upload(s) in s3://rb-force",
+        "delete: s3://rb-force/logs/b.txt (version",
+        "remove_bucket: s3://rb-force",
+        "Completed: 4 succeeded")
+
+    if testServer.HasBucket("rb-force") {
+        t.Errorf("the bucket should have been removed")
+    }
+}
+
+func TestRemoveBucketForceFailure(t *testing.T) {
+    testServer.PutObject("rb-force-denied", "keep.txt", []byte("keep"))
+    testServer.PutObject("rb-force-denied", "remove.txt", []byte("remove"))
+    testServer.DenyKey("rb-force-denied", "keep.txt")
+
+    _, code := runCommandWithExitCode(t, "rb", "s3://rb-force-denied", "--force")
+
+    if code != 1 {
+        t.Errorf("got exit code %d, want 1", code)
+    }
+
+    // Whatever couldn't be deleted keeps the bucket around.
+    if !testServer.HasBucket("rb-force-denied") {
+        t.Errorf("the bucket should not have been removed")
+    }
+
+    assertKeys(t, "rb-force-denied", "keep.txt")
+}
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+    "context"
+    "errors"
+    "time"
+
     "github.com/spf13/cobra"
     "github.com/scruwys/s3go/internal"
 )
@@ -19,43 +23,88 @@ func restoreCommandHandler(cmd *cobra.Command, args []string) {
         s3go.ExitWithError(1, err)
     }
 
+    options := &s3go.RestoreObjectOptions{
+        Days:         flagDays,
+        RequestPayer: flagRequestPayer,
+    }
+
+    if flagTier != "" {
+        if options.Tier, err = s3go.ParseRestoreTier(flagTier); err != nil {
+            s3go.ExitWithError(1, err)
+        }
+    }
+
+    if options.Days < 1 {
+        s3go.ExitWithError(1, errors.New("--days must be at least 1."))
+    }
+
+    followUp := validateRestoreWaitFlags()
+
+    state, err := s3go.LoadRestoreStateFile(flagStateFile)
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
     client := newClientWithRegionFromBucket(uri.Bucket)
-    doneCh := make(chan bool)
-
-    objectCh, err := client.ListObjectsV2(&s3go.ListObjectsV2Input{
-        Bucket:        uri.Bucket,
-        Prefix:        uri.Prefix,
-        Recursive:     flagRecursive,
-        IncludeFilter: flagIncludeFilter,
-        ExcludeFilter: flagExcludeFilter,
+    ctx := newCommandContext()
+
+    objectCh, err := client.ListObjectsV2(ctx, &s3go.ListObjectsV2Input{
+        Bucket:    uri.Bucket,
+        Prefix:    uri.Prefix,
+        Recursive: flagRecursive,
+        Filters:   filtersFromFlags(),
     })
 
     if err != nil {
         s3go.ExitWithError(1, err)
     }
 
-    workerInput := &restoreCommandWorkerInput{objectCh, doneCh}
-    workers := make([]<-chan s3go.ObjectInfo, flagConcurrency)
+    progress := newProgress(false)
+    progress.Start()
+
+    workerInput := &restoreCommandWorkerInput{progress.Track(ctx, exactObjects(ctx, objectCh, uri)), ctx, options, state}
+    workers := make([]<-chan s3go.ObjectResult, flagConcurrency)
 
     for i := 0; i < flagConcurrency; i++ {
         workers[i] = restoreCommandWorker(client, workerInput)
     }
 
-    for range s3go.MergeWaitWithObjectInfo(doneCh, workers...) {
-        continue
+    // Every object whose restore was requested is waited on.
+    waiting := []s3go.ObjectInfo{}
+
+    summary := collectResultsWith(progress, func(result s3go.ObjectResult) {
+        if result.Status == s3go.RESULT_SUCCEEDED {
+            waiting = append(waiting, result.Object)
+        }
+    }, workers...)
+
+    progress.Stop()
+
+    if flagWait && !flagDryRun && ctx.Err() == nil {
+        // Each object is counted once, by how waiting on it turned out.
+        summary.Succeeded = 0
+        summary.Merge(waitForRestores(ctx, client, uri, waiting, options, followUp, state))
     }
+
+    exitWithSummary(ctx, summary)
 }
 
 type restoreCommandWorkerInput struct {
     // Channel
     objectCh <-chan s3go.ObjectInfo
 
-    // Channel
-    doneCh <-chan bool
+    // Cancelled when the command is interrupted
+    ctx context.Context
+
+    options *s3go.RestoreObjectOptions
+
+    // Objects a previous --wait run already got to
+    state *s3go.RestoreStateFile
 }
 
-func restoreCommandWorker(client *s3go.Client, input *restoreCommandWorkerInput) <-chan s3go.ObjectInfo {
-    resultCh := make(chan s3go.ObjectInfo)
+func restoreCommandWorker(client *s3go.Client, input *restoreCommandWorkerInput) <-chan s3go.ObjectResult {
+    resultCh := make(chan s3go.ObjectResult)
 
     // We append this to output when we are doing a dry run.
     dryRunPrefix := ""
@@ -67,24 +116,50 @@ func restoreCommandWorker(client *s3go.Client, input *restoreCommandWorkerInput)
     go func() {
         defer close(resultCh)
         for item := range input.objectCh {
-            select {
-                case <-input.doneCh:
-                    return
-                case resultCh <- item:
-                    err := error(nil)
-
-                    if !flagDryRun {
-                        err = client.RestoreObject(*item.Bucket, *item.Key, flagRequestPayer)
-
-                        if err != nil {
-                            s3go.Echo("%v", err)
-                        }
-                    }
-
-                    if err == nil && !flagQuiet {
-                        s3go.Echo("%srestore: s3://%s/%s", dryRunPrefix, *item.Bucket, *item.Key)
-                    }
+            // Nothing new is started once the command has been interrupted.
+            if input.ctx.Err() != nil {
+                return
+            }
+
+            if item.Err != nil {
+                resultCh <- newListingFailedResult(item)
+                continue
+            }
+
+            if item.IsPrefix {
+                continue
+            }
+
+            // Only archived objects can be restored, everything else can already be read.
+            if !item.IsArchived() {
+                resultCh <- s3go.NewSkippedResult(item, "")
+                continue
+            }
+
+            if flagStatus {
+                status, err := client.GetRestoreStatus(input.ctx, item, flagRequestPayer)
+                resultCh <- newObjectResult(input.ctx, item, "restore-status: " + item.Url() + " " + status.String(), "restore-status failed: " + item.Url(), err)
+                continue
+            }
+
+            step := input.state.Step(item)
+
+            if step == s3go.RESTORE_STEP_DONE {
+                resultCh <- s3go.NewSkippedResult(item, "")
+                continue
             }
+
+            err := error(nil)
+
+            if !flagDryRun && step != s3go.RESTORE_STEP_REQUESTED {
+                err = client.RestoreObject(input.ctx, item, input.options)
+
+                if err == nil {
+                    err = input.state.SetStep(item, s3go.RESTORE_STEP_REQUESTED)
+                }
+            }
+
+            resultCh <- newObjectResult(input.ctx, item, dryRunPrefix + "restore: " + item.Url(), "restore failed: " + item.Url(), err)
         }
     }()
     return resultCh
@@ -97,6 +172,54 @@ func init() {
         false,
         "Displays the operations that would be performed using the specified command without actually running them.")
 
+    restoreCommand.Flags().Int64Var(
+        &flagDays,
+        "days",
+        1,
+        "Number of days the restored copy is kept for before it is deleted again.")
+
+    restoreCommand.Flags().StringVar(
+        &flagTier,
+        "tier",
+        "",
+        "Retrieval tier: Expedited, Standard or Bulk. Defaults to Standard.")
+
+    restoreCommand.Flags().BoolVar(
+        &flagStatus,
+        "status",
+        false,
+        "Reports whether each object's restore is not-started, in-progress or available, instead of starting one.")
+
+    restoreCommand.Flags().BoolVar(
+        &flagWait,
+        "wait",
+        false,
+        "Waits until every restored object is available, checking with an increasing interval.")
+
+    restoreCommand.Flags().DurationVar(
+        &flagPollInterval,
+        "poll-interval",
+        time.Minute,
+        "How long --wait waits between checks of the restores at first. Doubles after every check, up to 30 minutes.")
+
+    restoreCommand.Flags().StringVar(
+        &flagCopyTo,
+        "copy-to",
+        "",
+        "With --wait, copies each object to this S3 prefix or local directory once it is restored.")
+
+    restoreCommand.Flags().StringVar(
+        &flagStorageClass,
+        "storage-class",
+        "",
+        "With --wait, changes the storage class of each object in place once it is restored (e.g., STANDARD).")
+
+    restoreCommand.Flags().StringVar(
+        &flagStateFile,
+        "state-file",
+        "",
+        "With --wait, records the progress of each object in this file, so that an interrupted run can be resumed by running it again.")
+
     restoreCommand.Flags().BoolVar(
         &flagQuiet,
         "quiet",
@@ -109,17 +232,7 @@ func init() {
         false,
         "Command is performed on all files or objects under the specified directory or prefix.")
 
-    restoreCommand.Flags().StringVar(
-        &flagIncludeFilter,
-        "include",
-        "",
-        "Don't exclude files or objects in the command that match the specified pattern.")
-
-    restoreCommand.Flags().StringVar(
-        &flagExcludeFilter,
-        "exclude",
-        "",
-        "Exclude all files or objects from the command that matches the specified pattern.")
+    addFilterFlags(restoreCommand)
 
     restoreCommand.Flags().StringVar(
         &flagRequestPayer,
@@ -127,6 +240,12 @@ func init() {
         "",
         "Confirms that the requester knows that she or he will be charged for the request.")
 
+    restoreCommand.Flags().BoolVar(
+        &flagNoProgress,
+        "no-progress",
+        false,
+        "Progress is not displayed.")
+
     restoreCommand.Flags().IntVar(
         &flagConcurrency,
         "concurrency",
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+// Stores an object in an archive storage class, as if it had been transitioned there.
+func putArchivedObject(bucket, key, storageClass string) {
+    testServer.PutObject(bucket, key, []byte(key)).Header.Set("X-Amz-Storage-Class", storageClass)
+}
+
+func TestRestoreRecursive(t *testing.T) {
+    putArchivedObject("restore-bucket", "archive/a.bin", "GLACIER")
+    putArchivedObject("restore-bucket", "archive/b.bin", "DEEP_ARCHIVE")
+    testServer.PutObject("restore-bucket", "archive/c.bin", []byte("c"))
+
+    output := runCommand(t, "restore", "s3://restore-bucket/archive/", "--recursive", "--days", "7", "--tier", "bulk")
+
+    assertContains(t, output, "restore: s3://restore-bucket/archive/a.bin", "restore: s3://restore-bucket/archive/b.bin", "2 succeeded, 0 failed, 1 skipped")
+
+    for _, key := range []string{"archive/a.bin", "archive/b.bin"} {
+        object := testServer.GetObject("restore-bucket", key)
+
+        if object.Restore == "" {
+            t.Errorf("%s should have a restore in progress", key)
+        }
+
+        if object.RestoreDays != 7 || object.RestoreTier != "Bulk" {
+            t.Errorf("%s was restored for %d days with tier %q, want 7 days with Bulk", key, object.RestoreDays, object.RestoreTier)
+        }
+    }
+
+    // Objects that aren't archived can already be read, so they're skipped.
+    if testServer.GetObject("restore-bucket", "archive/c.bin").Restore != "" {
+        t.Errorf("archive/c.bin should not have been restored")
+    }
+}
+
+func TestRestoreStatus(t *testing.T) {
+    putArchivedObject("restore-status", "a.bin", "GLACIER")
+    putArchivedObject("restore-status", "b.bin", "GLACIER")
+    putArchivedObject("restore-status", "c.bin", "GLACIER")
+
+    runCommand(t, "restore", "s3://restore-status/b.bin")
+    runCommand(t, "restore", "s3://restore-status/c.bin")
+    testServer.CompleteRestore("restore-status", "c.bin", time.Date(2020, 10, 8, 0, 0, 0, 0, time.UTC))
+
+    output := runCommand(t, "restore", "s3://restore-status/", "--recursive", "--status")
+
+    assertContains(t, output,
+        "restore-status: s3://restore-status/a.bin not-started",
+        "restore-status: s3://restore-status/b.bin in-progress",
+        "restore-status: s3://restore-status/c.bin available until 2020-10-08 00:00:00")
+}
+
+func TestRestoreWaitAndCopy(t *testing.T) {
+    testServer.RestoreDuration = 50 * time.Millisecond
+    defer func() { testServer.RestoreDuration = 0 }()
+
+    putArchivedObject("restore-wait", "archive/a.bin", "DEEP_ARCHIVE")
+    putArchivedObject("restore-wait", "archive/b.bin", "DEEP_ARCHIVE")
+    testServer.CreateBucket("restore-wait-target")
+
+    output := runCommand(t, "restore", "s3://restore-wait/archive/", "--recursive", "--wait", "--poll-interval", "20ms", "--copy-to", "s3://restore-wait-target/thawed/")
+
+    assertContains(t, output,
+        "waiting: 2 object(s) still restoring",
+        "copy: s3://restore-wait/archive/a.bin to s3://restore-wait-target/thawed/a.bin",
+        "copy: s3://restore-wait/archive/b.bin to s3://restore-wait-target/thawed/b.bin",
+        "Completed: 2 succeeded")
+
+    assertKeys(t, "restore-wait-target", "thawed/a.bin", "thawed/b.bin")
+}
+
+func TestRestoreWaitResumesFromStateFile(t *testing.T) {
+    testServer.RestoreDuration = 50 * time.Millisecond
+    defer func() { testServer.RestoreDuration = 0 }()
+
+    putArchivedObject("restore-resume", "a.bin", "GLACIER")
+    putArchivedObject("restore-resume", "b.bin", "GLACIER")
+
+    archived := testServer.GetObject("restore-resume", "b.bin")
+    archived.Header.Set("Content-Type", "application/x-tar")
+    archived.Header.Set("X-Amz-Acl", "public-read")
+    archived.Header.Set("X-Amz-Server-Side-Encryption", "aws:kms")
+    archived.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", "arn:aws:kms:us-east-1:123456789012:key/abc")
+
+    // A previous run finished a.bin and requested b.bin before it was interrupted.
+    runCommand(t, "restore", "s3://restore-resume/b.bin")
+
+    dir := createTestDir(t, map[string]string{
+        "state.json": `{"objects": {"s3://restore-resume/a.bin": "done", "s3://restore-resume/b.bin": "requested"}}`,
+    })
+    defer os.RemoveAll(dir)
+
+    stateFile := filepath.Join(dir, "state.json")
+    output := runCommand(t, "restore", "s3://restore-resume/", "--recursive", "--days", "5", "--wait", "--poll-interval", "20ms", "--storage-class", "STANDARD", "--state-file", stateFile)
+
+    assertContains(t, output, "storage-class: s3://restore-resume/b.bin to STANDARD", "1 succeeded, 0 failed, 1 skipped")
+
+    if class := testServer.GetObject("restore-resume", "a.bin").Header.Get("X-Amz-Storage-Class"); class != "GLACIER" {
+        t.Errorf("a.bin was already done and should have been left alone, got storage class %s", class)
+    }
+
+    // Only the storage class changes.
+    assertHeaders(t, "restore-resume", "b.bin", map[string]string{
+        "X-Amz-Storage-Class":                         "STANDARD",
+        "Content-Type":                                "application/x-tar",
+        "X-Amz-Server-Side-Encryption":                "aws:kms",
+        "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": "arn:aws:kms:us-east-1:123456789012:key/abc",
+    })
+
+    if grants := testServer.GetObject("restore-resume", "b.bin").Grants(); len(grants) != 2 {
+        t.Errorf("got grants %v for b.bin, want the public-read ones kept", grants)
+    }
+
+    state, err := ioutil.ReadFile(stateFile)
+    if err != nil || !strings.Contains(string(state), `"s3://restore-resume/b.bin": "done"`) {
+        t.Errorf("b.bin should be marked done in the state file, got %s (%v)", state, err)
+    }
+}
+
+func TestRestoreWaitRequestsStaleRestoresAgain(t *testing.T) {
+    testServer.RestoreDuration = 50 * time.Millisecond
+    defer func() { testServer.RestoreDuration = 0 }()
+
+    putArchivedObject("restore-stale", "a.bin", "GLACIER")
+
+    // The state file says the restore was requested, but it never started or has since expired.
+    dir := createTestDir(t, map[string]string{
+        "state.json": `{"objects": {"s3://restore-stale/a.bin": "requested"}}`,
+    })
+    defer os.RemoveAll(dir)
+
+    output := runCommand(t, "restore", "s3://restore-stale/a.bin", "--days", "3", "--wait", "--poll-interval", "20ms",
+        "--copy-to", "s3://restore-stale/thawed/", "--state-file", filepath.Join(dir, "state.json"))
+
+    assertContains(t, output, "copy: s3://restore-stale/a.bin to s3://restore-stale/thawed/a.bin", "Completed: 1 succeeded")
+
+    if days := testServer.GetObject("restore-stale", "a.bin").RestoreDays; days != 3 {
+        t.Errorf("got a restore for %d days, want it requested again for 3", days)
+    }
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+
+    "github.com/scruwys/s3go/internal"
+)
+
+// Checks for restores are never further apart than this, however long they've been running.
+const MAX_POLL_INTERVAL = 30 * time.Minute
+
+// What to do with each object once it has been restored.
+type restoreFollowUp struct {
+    // Copy the object here. Nil if it isn't copied.
+    target *s3go.S3Url
+
+    // Change the object's storage class in place to this. Empty if it isn't changed.
+    storageClass string
+}
+
+// Checks the flags that only make sense with --wait, returning what to do once objects are restored.
+func validateRestoreWaitFlags() *restoreFollowUp {
+    followUp := &restoreFollowUp{storageClass: flagStorageClass}
+
+    if !flagWait {
+        if flagCopyTo != "" || flagStorageClass != "" || flagStateFile != "" {
+            s3go.ExitWithError(1, errors.New("--copy-to, --storage-class and --state-file can only be used with --wait."))
+        }
+        return followUp
+    }
+
+    if flagStatus {
+        s3go.ExitWithError(1, errors.New("--status and --wait can't be used together."))
+    }
+
+    if flagCopyTo != "" && flagStorageClass != "" {
+        s3go.ExitWithError(1, errors.New("Only one of --copy-to and --storage-class may be used."))
+    }
+
+    if flagPollInterval <= 0 {
+        s3go.ExitWithError(1, errors.New("--poll-interval must be positive."))
+    }
+
+    if flagCopyTo != "" {
+        target, err := s3go.ParseUrl(flagCopyTo)
+
+        if err != nil {
+            s3go.ExitWithError(1, err)
+        }
+
+        followUp.target = target
+    }
+
+    return followUp
+}
+
+// Polls the restore status of the objects, backing off between checks, until every one of them is
+// available or the command is interrupted. Each object is followed up as soon as it is available.
+func waitForRestores(ctx context.Context, client *s3go.Client, source *s3go.S3Url, objects []s3go.ObjectInfo, options *s3go.RestoreObjectOptions, followUp *restoreFollowUp, state *s3go.RestoreStateFile) *s3go.ResultSummary {
+    summary := &s3go.ResultSummary{}
+    interval := flagPollInterval
+
+    progress := newProgress(false)
+    progress.Start()
+
+    for range objects {
+        progress.AddTotal(0)
+    }
+    progress.ListingDone()
+
+    for len(objects) > 0 {
+        // Objects that were restored by an earlier run are picked up straight away.
+        objects = pollRestores(ctx, client, source, objects, options, followUp, state, progress, summary)
+
+        if len(objects) == 0 || ctx.Err() != nil {
+            break
+        }
+
+        if !flagQuiet && !flagOnlyShowErrors {
+            s3go.Echo("waiting: %d object(s) still restoring, checking again in %s", len(objects), interval)
+        }
+
+        select {
+            case <-time.After(interval):
+            case <-ctx.Done():
+        }
+
+        interval *= 2
+        if interval > MAX_POLL_INTERVAL {
+            interval = MAX_POLL_INTERVAL
+        }
+    }
+
+    progress.Stop()
+    return summary
+}
+
+// Checks every object once, following up the ones that are available and adding their results to
+// summary. Returns the objects that are still being restored.
+func pollRestores(ctx context.Context, client *s3go.Client, source *s3go.S3Url, objects []s3go.ObjectInfo, options *s3go.RestoreObjectOptions, followUp *restoreFollowUp, state *s3go.RestoreStateFile, progress *s3go.Progress, summary *s3go.ResultSummary) []s3go.ObjectInfo {
+    objectCh := make(chan s3go.ObjectInfo)
+
+    go func() {
+        defer close(objectCh)
+
+        for _, object := range objects {
+            select {
+                case objectCh <- object:
+                case <-ctx.Done():
+                    return
+            }
+        }
+    }()
+
+    workerInput := &restoreWaitWorkerInput{objectCh: objectCh, ctx: ctx, source: source, options: options, followUp: followUp, state: state}
+    workers := make([]<-chan s3go.ObjectResult, flagConcurrency)
+
+    for i := 0; i < flagConcurrency; i++ {
+        workers[i] = restoreWaitWorker(client, workerInput)
+    }
+
+    summary.Merge(collectResults(progress, workers...))
+
+    return workerInput.pending
+}
+
+type restoreWaitWorkerInput struct {
+    // Objects to check
+    objectCh <-chan s3go.ObjectInfo
+
+    // Cancelled when the command is interrupted
+    ctx context.Context
+
+    source *s3go.S3Url
+
+    // How restores that aren't running are requested again
+    options *s3go.RestoreObjectOptions
+
+    followUp *restoreFollowUp
+
+    state *s3go.RestoreStateFile
+
+    mu sync.Mutex
+
+    // Objects that aren't available yet
+    pending []s3go.ObjectInfo
+}
+
+func restoreWaitWorker(client *s3go.Client, input *restoreWaitWorkerInput) <-chan s3go.ObjectResult {
+    resultCh := make(chan s3go.ObjectResult)
+
+    go func() {
+        defer close(resultCh)
+        for item := range input.objectCh {
+            // Nothing new is started once the command has been interrupted.
+            if input.ctx.Err() != nil {
+                return
+            }
+
+            status, err := client.GetRestoreStatus(input.ctx, item, flagRequestPayer)
+
+            if err != nil {
+                resultCh <- newObjectResult(input.ctx, item, "", "restore-status failed: " + item.Url(), err)
+                continue
+            }
+
+            // The restore expired before it was followed up, or a state file from an earlier run says
+            // it was requested when it never started. Either way it has to be requested again.
+            if status.State == s3go.RESTORE_NOT_STARTED {
+                if err := client.RestoreObject(input.ctx, item, input.options); err != nil {
+                    resultCh <- newObjectResult(input.ctx, item, "", "restore failed: " + item.Url(), err)
+                    continue
+                }
+
+                status.State = s3go.RESTORE_IN_PROGRESS
+            }
+
+            if status.State == s3go.RESTORE_IN_PROGRESS {
+                input.mu.Lock()
+                input.pending = append(input.pending, item)
+                input.mu.Unlock()
+                continue
+            }
+
+            logMessage, failure, err := followUpRestore(input.ctx, client, item, input.source, input.followUp, status)
+
+            if err == nil {
+                err = input.state.SetStep(item, s3go.RESTORE_STEP_DONE)
+            }
+
+            resultCh <- newObjectResult(input.ctx, item, logMessage, failure, err)
+        }
+    }()
+    return resultCh
+}
+
+// Copies the object or changes its storage class, depending on what was asked for.
+func followUpRestore(ctx context.Context, client *s3go.Client, item s3go.ObjectInfo, source *s3go.S3Url, followUp *restoreFollowUp, status s3go.RestoreStatus) (string, string, error) {
+    switch {
+    case followUp.target != nil:
+        logMessage, err := client.MoveObject(ctx, item, &s3go.MoveObjectOptions{
+            Source:       source,
+            Target:       followUp.target,
+            Recursive:    flagRecursive,
+            RequestPayer: flagRequestPayer,
+        })
+        return "copy: " + logMessage, "copy failed: " + item.Url(), err
+    case followUp.storageClass != "":
+        err := client.ChangeStorageClass(ctx, item, followUp.storageClass, flagRequestPayer)
+        return "storage-class: " + item.Url() + " to " + followUp.storageClass, "storage-class failed: " + item.Url(), err
+    }
+
+    return "restored: " + item.Url() + " " + status.String(), "", nil
+}
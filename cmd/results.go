@@ -0,0 +1,89 @@
+package cmd
+
+import (
+    "context"
+    "os"
+
+    "github.com/scruwys/s3go/internal"
+)
+
+// Exits the process. Swapped out by tests so that exit codes can be checked.
+var exit = os.Exit
+
+// The exit code used by shells for a process stopped with SIGINT.
+const interruptedExitCode = 130
+
+// Builds the result of running a command against an object. Errors caused by the command being
+// interrupted are reported as cancelled rather than failed.
+func newObjectResult(ctx context.Context, item s3go.ObjectInfo, message, failure string, err error) s3go.ObjectResult {
+    switch {
+    case err == nil:
+        return s3go.NewSucceededResult(item, message)
+    case ctx.Err() != nil:
+        return s3go.NewCancelledResult(item, failure)
+    default:
+        return s3go.NewFailedResult(item, failure, err)
+    }
+}
+
+// The result for the item a listing ends with when it fails, so the command exits non-zero.
+func newListingFailedResult(item s3go.ObjectInfo) s3go.ObjectResult {
+    return s3go.NewFailedResult(item, "list failed: " + item.Url(), item.Err)
+}
+
+// A progress reporter for the command, or nil when progress is turned off or output is suppressed.
+func newProgress(countBytes bool) *s3go.Progress {
+    if flagNoProgress || flagQuiet || flagOnlyShowErrors {
+        return nil
+    }
+    return s3go.NewProgress(os.Stderr, s3go.IsTerminal(os.Stderr), countBytes)
+}
+
+// Waits for every worker to finish, printing and tallying their results along the way.
+func collectResults(progress *s3go.Progress, workers ...<-chan s3go.ObjectResult) *s3go.ResultSummary {
+    return collectResultsWith(progress, nil, workers...)
+}
+
+// Like collectResults, but also hands every result to onResult, if it's set.
+func collectResultsWith(progress *s3go.Progress, onResult func(s3go.ObjectResult), workers ...<-chan s3go.ObjectResult) *s3go.ResultSummary {
+    summary := &s3go.ResultSummary{}
+
+    for result := range s3go.MergeWaitWithObjectResult(workers...) {
+        summary.Add(result)
+        progress.ObjectDone(result)
+
+        if onResult != nil {
+            onResult(result)
+        }
+
+        switch result.Status {
+        case s3go.RESULT_FAILED:
+            s3go.EchoError("%s %v", result.Message, result.Err)
+        case s3go.RESULT_SUCCEEDED:
+            if !flagQuiet && !flagOnlyShowErrors {
+                s3go.Echo("%s", result.Message)
+            }
+        }
+    }
+
+    return summary
+}
+
+// Prints the end of run summary and exits with a non-zero code if anything failed.
+// Runs that had nothing to do, like an up to date sync, stay silent.
+func exitWithSummary(ctx context.Context, summary *s3go.ResultSummary) {
+    // Whatever finished before an interrupt is always reported, so the run can be picked up again.
+    if ctx.Err() != nil {
+        s3go.EchoError("Interrupted. %s", summary)
+        exit(interruptedExitCode)
+        return
+    }
+
+    if summary.Total() > 0 && !flagQuiet && (!flagOnlyShowErrors || summary.Failed > 0) {
+        s3go.Echo("%s", summary)
+    }
+
+    if code := summary.ExitCode(); code != 0 {
+        exit(code)
+    }
+}
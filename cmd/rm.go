@@ -1,6 +1,7 @@
 package cmd
 
 import (
+    "context"
 
     "github.com/spf13/cobra"
     "github.com/scruwys/s3go/internal"
@@ -8,7 +9,7 @@ import (
 
 var removeCommand = &cobra.Command{
     Use:   "rm",
-    Short: "Deletes an S3 object.",
+    Short: "Deletes an S3 object or local file.",
     Args:  cobra.ExactArgs(1),
     Run:   removeCommandHandler,
 }
@@ -20,43 +21,56 @@ func removeCommandHandler(cmd *cobra.Command, args []string) {
         s3go.ExitWithError(1, err)
     }
 
-    client := newClientWithRegionFromBucket(uri.Bucket)
-    doneCh := make(chan bool)
+    validateVersionIdFlag(uri)
 
-    objectCh, err := client.ListObjectsV2(&s3go.ListObjectsV2Input{
-        Bucket:        uri.Bucket,
-        Prefix:        uri.Prefix,
-        Recursive:     flagRecursive,
-        IncludeFilter: flagIncludeFilter,
-        ExcludeFilter: flagExcludeFilter,
+    client := newClientForTransfer(uri, uri)
+    ctx := newCommandContext()
+
+    objectCh, err := client.ListSourceObjects(ctx, &s3go.ListSourceObjectsInput{
+        SourceUrl: uri,
+        Recursive: flagRecursive,
+        Filters:   filtersFromFlags(),
+        VersionId: flagVersionId,
     })
 
     if err != nil {
         s3go.ExitWithError(1, err)
     }
 
-    workerInput := &removeCommandWorkerInput{objectCh, doneCh}
-    workers := make([]<-chan s3go.ObjectInfo, flagConcurrency)
+    progress := newProgress(false)
+    progress.Start()
+
+    summary := runRemoveWorkers(ctx, client, progress, progress.Track(ctx, exactObjects(ctx, objectCh, uri)))
+
+    progress.Stop()
+    exitWithSummary(ctx, summary)
+}
+
+// Groups the objects in objectCh into batches of up to 1000 keys, fans them out to flagConcurrency
+// delete workers and waits for them.
+func runRemoveWorkers(ctx context.Context, client *s3go.Client, progress *s3go.Progress, objectCh <-chan s3go.ObjectInfo) *s3go.ResultSummary {
+    batchCh := s3go.BatchObjects(ctx, objectCh, s3go.DELETE_BATCH_SIZE)
+
+    workerInput := &removeCommandWorkerInput{batchCh, ctx}
+    workers := make([]<-chan s3go.ObjectResult, flagConcurrency)
 
     for i := 0; i < flagConcurrency; i++ {
         workers[i] = removeCommandWorker(client, workerInput)
     }
 
-    for range s3go.MergeWaitWithObjectInfo(doneCh, workers...) {
-        continue
-    }
+    return collectResults(progress, workers...)
 }
 
 type removeCommandWorkerInput struct {
-    // Channel
-    objectCh <-chan s3go.ObjectInfo
+    // Batches of objects to delete with a single request each
+    batchCh <-chan []s3go.ObjectInfo
 
-    // Channel
-    doneCh <-chan bool
+    // Cancelled when the command is interrupted
+    ctx context.Context
 }
 
-func removeCommandWorker(client *s3go.Client, input *removeCommandWorkerInput) <-chan s3go.ObjectInfo {
-    resultCh := make(chan s3go.ObjectInfo)
+func removeCommandWorker(client *s3go.Client, input *removeCommandWorkerInput) <-chan s3go.ObjectResult {
+    resultCh := make(chan s3go.ObjectResult)
     // We append this to output when we are doing a dry run.
     dryRunPrefix := ""
     if flagDryRun {
@@ -65,24 +79,32 @@ func removeCommandWorker(client *s3go.Client, input *removeCommandWorkerInput) <
 
     go func() {
         defer close(resultCh)
-        for item := range input.objectCh {
-            select {
-                case <-input.doneCh:
-                    return
-                case resultCh <- item:
-                    err := error(nil)
-
-                    if !flagDryRun {
-                        err = client.DeleteObject(*item.Bucket, *item.Key, flagRequestPayer)
-
-                        if err != nil {
-                            s3go.Echo("%v", err)
-                        }
-                    }
-
-                    if err == nil && !flagQuiet && !flagOnlyShowErrors {
-                        s3go.Echo("%sdelete: s3://%s/%s", dryRunPrefix, *item.Bucket, *item.Key)
-                    }
+        for batch := range input.batchCh {
+            // Nothing new is started once the command has been interrupted.
+            if input.ctx.Err() != nil {
+                return
+            }
+
+            // Failed listings arrive in a batch of their own.
+            if batch[0].Err != nil {
+                resultCh <- newListingFailedResult(batch[0])
+                continue
+            }
+
+            errs := make([]error, len(batch))
+
+            if !flagDryRun {
+                errs = client.RemoveObjects(input.ctx, batch, flagRequestPayer)
+            }
+
+            for i, item := range batch {
+                url := item.Url()
+
+                if item.VersionId != nil {
+                    url += " (version " + *item.VersionId + ")"
+                }
+
+                resultCh <- newObjectResult(input.ctx, item, dryRunPrefix + "delete: " + url, "delete failed: " + url, errs[i])
             }
         }
     }()
@@ -109,16 +131,12 @@ func init() {
         "Command is performed on all files or objects under the specified directory or prefix.")
 
     removeCommand.Flags().StringVar(
-        &flagIncludeFilter,
-        "include",
+        &flagVersionId,
+        "version-id",
         "",
-        "Don't exclude files or objects in the command that match the specified pattern.")
+        "Permanently deletes a specific version of the object instead of adding a delete marker.")
 
-    removeCommand.Flags().StringVar(
-        &flagExcludeFilter,
-        "exclude",
-        "",
-        "Exclude all files or objects from the command that matches the specified pattern.")
+    addFilterFlags(removeCommand)
 
     removeCommand.Flags().StringVar(
         &flagRequestPayer,
@@ -132,6 +150,12 @@ func init() {
         false,
         "Only errors and warnings are displayed. All other output is suppressed.")
 
+    removeCommand.Flags().BoolVar(
+        &flagNoProgress,
+        "no-progress",
+        false,
+        "Progress is not displayed.")
+
     removeCommand.Flags().IntVar(
         &flagConcurrency,
         "concurrency",
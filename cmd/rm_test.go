@@ -0,0 +1,146 @@
+package cmd
+
+import (
+    "context"
+    "fmt"
+    "testing"
+)
+
+func TestRemoveObject(t *testing.T) {
+    testServer.PutObject("rm-single", "keep.txt", []byte("keep"))
+    testServer.PutObject("rm-single", "remove.txt", []byte("remove"))
+    testServer.PutObject("rm-single", "remove.txt.bak", []byte("keep"))
+
+    output := runCommand(t, "rm", "s3://rm-single/remove.txt")
+
+    assertContains(t, output, "delete: s3://rm-single/remove.txt", "Completed: 1 succeeded")
+    assertKeys(t, "rm-single", "keep.txt", "remove.txt.bak")
+}
+
+func TestRemoveRecursiveWithFilters(t *testing.T) {
+    testServer.PutObject("rm-recursive", "tmp/a.log", []byte("a"))
+    testServer.PutObject("rm-recursive", "tmp/b.log", []byte("b"))
+    testServer.PutObject("rm-recursive", "tmp/c.txt", []byte("c"))
+    testServer.PutObject("rm-recursive", "other/d.log", []byte("d"))
+
+    runCommand(t, "rm", "s3://rm-recursive/tmp/", "--recursive", "--exclude", "*.txt", "--concurrency", "3")
+
+    assertKeys(t, "rm-recursive", "other/d.log", "tmp/c.txt")
+}
+
+func TestRemoveWithOrderedFilters(t *testing.T) {
+    testServer.PutObject("rm-ordered", "logs/a.log", []byte("a"))
+    testServer.PutObject("rm-ordered", "logs/b.csv", []byte("b"))
+    testServer.PutObject("rm-ordered", "logs/keep/c.csv", []byte("c"))
+
+    // Later rules win, so only the .csv files outside keep/ are deleted.
+    runCommand(t, "rm", "s3://rm-ordered/logs/", "--recursive",
+        "--exclude", "*", "--include", "*.csv", "--exclude", "keep/*")
+
+    assertKeys(t, "rm-ordered", "logs/a.log", "logs/keep/c.csv")
+}
+
+func TestRemoveWithRegexFilters(t *testing.T) {
+    testServer.PutObject("rm-regex", "tmp/a.log", []byte("a"))
+    testServer.PutObject("rm-regex", "tmp/b.txt", []byte("b"))
+
+    // Regexes are matched against the full key, the same as before glob patterns.
+    runCommand(t, "rm", "s3://rm-regex/tmp/", "--recursive", "--filter-regex", "--include", `^tmp/.*\.log$`)
+
+    assertKeys(t, "rm-regex", "tmp/b.txt")
+}
+
+func TestRemoveDryRun(t *testing.T) {
+    testServer.PutObject("rm-dryrun", "a.txt", []byte("a"))
+
+    output := runCommand(t, "rm", "s3://rm-dryrun/", "--recursive", "--dryrun")
+
+    assertContains(t, output, "(dryrun) delete: s3://rm-dryrun/a.txt")
+    assertKeys(t, "rm-dryrun", "a.txt")
+}
+
+func TestRemovePartialFailure(t *testing.T) {
+    testServer.PutObject("rm-partial", "a.txt", []byte("a"))
+    testServer.PutObject("rm-partial", "b.txt", []byte("b"))
+    testServer.DenyKey("rm-partial", "b.txt")
+
+    output, code := runCommandWithExitCode(t, "rm", "s3://rm-partial/", "--recursive")
+
+    if code != 1 {
+        t.Errorf("got exit code %d, want 1", code)
+    }
+
+    assertContains(t, output, "delete: s3://rm-partial/a.txt", "Completed: 1 succeeded, 1 failed, 0 skipped.")
+    assertKeys(t, "rm-partial", "b.txt")
+}
+
+func TestRemoveFailedListing(t *testing.T) {
+    testServer.PutObject("rm-unlistable", "a.txt", []byte("a"))
+    testServer.DenyListing("rm-unlistable")
+
+    output, code := runCommandWithExitCode(t, "rm", "s3://rm-unlistable/", "--recursive")
+
+    if code != 2 {
+        t.Errorf("got exit code %d, want 2", code)
+    }
+
+    assertContains(t, output, "Completed: 0 succeeded, 1 failed, 0 skipped.")
+    assertKeys(t, "rm-unlistable", "a.txt")
+}
+
+func TestRemoveInterrupted(t *testing.T) {
+    testServer.PutObject("rm-interrupted", "a.txt", []byte("a"))
+    testServer.PutObject("rm-interrupted", "b.txt", []byte("b"))
+
+    defer func() { newCommandContext = context.Background }()
+
+    newCommandContext = func() context.Context {
+        ctx, cancel := context.WithCancel(context.Background())
+        cancel()
+        return ctx
+    }
+
+    _, code := runCommandWithExitCode(t, "rm", "s3://rm-interrupted/", "--recursive")
+
+    if code != interruptedExitCode {
+        t.Errorf("got exit code %d, want %d", code, interruptedExitCode)
+    }
+
+    assertKeys(t, "rm-interrupted", "a.txt", "b.txt")
+}
+
+func TestRemoveRecursiveInBatches(t *testing.T) {
+    for i := 0; i < 2500; i++ {
+        testServer.PutObject("rm-batches", fmt.Sprintf("logs/%04d.log", i), []byte("x"))
+    }
+
+    before := testServer.BatchDeletes()
+
+    runCommand(t, "rm", "s3://rm-batches/logs/", "--recursive", "--concurrency", "3", "--quiet")
+
+    if batches := testServer.BatchDeletes() - before; batches != 3 {
+        t.Errorf("got %d DeleteObjects requests, want 3", batches)
+    }
+
+    assertKeys(t, "rm-batches")
+}
+
+func TestRemoveVersion(t *testing.T) {
+    testServer.EnableVersioning("rm-version")
+
+    first := testServer.PutObject("rm-version", "a.txt", []byte("one"))
+    testServer.PutObject("rm-version", "a.txt", []byte("two"))
+
+    output := runCommand(t, "rm", "s3://rm-version/a.txt", "--version-id", first.VersionId)
+
+    assertContains(t, output, "delete: s3://rm-version/a.txt (version "+first.VersionId+")")
+
+    // Only that version is gone. No delete marker is added and the current version is untouched.
+    if versions := testServer.Versions("rm-version", "a.txt"); len(versions) != 1 || versions[0].DeleteMarker {
+        t.Errorf("got %d versions, want just the current one", len(versions))
+    }
+
+    if body := string(testServer.GetObject("rm-version", "a.txt").Body); body != "two" {
+        t.Errorf("got %s, want two", body)
+    }
+}
@@ -1,7 +1,12 @@
 package cmd
 
 import (
-	"fmt"
+    "context"
+    "os"
+    "os/signal"
+    "strings"
+    "syscall"
+    "time"
 
     "github.com/spf13/cobra"
     "github.com/scruwys/s3go/internal"
@@ -10,28 +15,73 @@ import (
 // Persistent Flags
 var Debug bool
 var Endpoint string
-var VerifySSL bool
+var NoVerifySSL bool
 var Profile string
 var Region string
+var AddressingStyle string
+var Provider string
+var SignatureVersion string
+
+// Deprecated: use NoVerifySSL. It was bound to --no-verify-ssl too, so setting it still skips
+// certificate checks.
+var VerifySSL bool
 
 // Local Flags
 var flagACL string
+var flagCacheControl string
+var flagChecksum bool
 var flagConcurrency int
 var flagContentDisposition string
 var flagContentEncoding string
 var flagContentLanguage string
 var flagContentType string
+var flagDelete bool
 var flagDryRun bool
-var flagExcludeFilter string
+var flagExactTimestamps bool
 var flagExpiresIn int
+var flagExpectedSize int64
+var flagExpires string
+var flagFilterRegex bool
+var flagFilters []s3go.FilterRule
 var flagForce bool
+var flagAsOf string
+var flagDays int64
+var flagTier string
+var flagStatus bool
+var flagWait bool
+var flagPollInterval time.Duration
+var flagCopyTo string
+var flagStorageClass string
+var flagStateFile string
 var flagHumanReadable bool
-var flagIncludeFilter string
+var flagMetadata string
+var flagMetadataDirective string
+var flagMimeTypes string
+var flagGuessMimeTypeFromContent bool
+var flagNoGuessMimeType bool
+var flagNoProgress bool
 var flagOnlyShowErrors bool
+var flagPartSize int64
+var flagMultipartThreshold int64
+var flagMultipartConcurrency int
 var flagQuiet bool
 var flagRecursive bool
 var flagRequestPayer string
+var flagResume bool
+var flagSizeOnly bool
+var flagSSE string
+var flagSSEC string
+var flagSSECCopySource string
+var flagSSEKMSContext string
+var flagSSEKMSKeyId string
+var flagSourceEndpoint string
+var flagSourceProfile string
+var flagSourceRegion string
 var flagSummarize bool
+var flagTagging string
+var flagVersionId string
+var flagVersions bool
+var flagWebsiteRedirect string
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
@@ -39,35 +89,85 @@ var RootCmd = &cobra.Command{
 	Short: "golang cli for interacting with aws s3.",
 }
 
+// A context that is cancelled on SIGINT or SIGTERM, so in-flight work can be wound down cleanly.
+// A second signal exits straight away. Swapped out by tests.
+var newCommandContext = func() context.Context {
+    ctx, cancel := context.WithCancel(context.Background())
+
+    signalCh := make(chan os.Signal, 1)
+    signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+
+    go func() {
+        sig := <-signalCh
+        s3go.EchoError("Received %v, cancelling in-flight requests. Send it again to exit immediately.", sig)
+        cancel()
+
+        <-signalCh
+        os.Exit(interruptedExitCode)
+    }()
+
+    return ctx
+}
+
 // Make a new s3go.Client using the default persistent flags
 func newClientWithPersistentFlags() *s3go.Client {
-	if Endpoint == "" {
-		Endpoint = s3go.DEFAULT_ENDPOINT_URL
-	}
-
-    return s3go.NewClient(&s3go.ClientOptions{
-        Endpoint:   Endpoint,
-        Debug:      Debug,
-        Profile:    Profile,
-        Region:     Region,
-        DisableSSL: !VerifySSL,
-    })
+    options := &s3go.ClientOptions{
+        Endpoint:             Endpoint,
+        Debug:                Debug,
+        Profile:              Profile,
+        Region:               Region,
+        NoVerifySSL:          NoVerifySSL || VerifySSL,
+        PartSize:             flagPartSize,
+        MultipartThreshold:   flagMultipartThreshold,
+        MultipartConcurrency: flagMultipartConcurrency,
+        AddressingStyle:      AddressingStyle,
+        SignatureVersion:     SignatureVersion,
+        Provider:             Provider,
+        Source:               sourceClientOptions(),
+    }
+
+    if err := s3go.ValidateClientOptions(options); err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    return s3go.NewClient(options)
 }
 
-// Overrides some flags to pull the region from the bucket
+// Client options for the source of a transfer, or nil if no --source-* flag was given. The source
+// is usually another account or system entirely, so it doesn't inherit --profile, --endpoint-url,
+// --region or --provider.
+func sourceClientOptions() *s3go.ClientOptions {
+    if flagSourceProfile == "" && flagSourceEndpoint == "" && flagSourceRegion == "" {
+        return nil
+    }
+
+    return &s3go.ClientOptions{
+        Endpoint:             flagSourceEndpoint,
+        Debug:                Debug,
+        Profile:              flagSourceProfile,
+        Region:               flagSourceRegion,
+        NoVerifySSL:          NoVerifySSL || VerifySSL,
+        PartSize:             flagPartSize,
+        MultipartThreshold:   flagMultipartThreshold,
+        MultipartConcurrency: flagMultipartConcurrency,
+    }
+}
+
+// Overrides the region flag with the region of the bucket. Any --endpoint-url is left as is.
 func newClientWithRegionFromBucket(bucketName string) *s3go.Client {
-    region, err := s3go.GetBucketRegion(bucketName)
+    client := newClientWithPersistentFlags()
+    region, err := client.GetBucketRegion(bucketName)
 
     if err != nil {
         s3go.ExitWithError(1, err)
     }
 
-    Region = region
-
-    if Endpoint == "" {
-		Endpoint = fmt.Sprintf("s3.%s.amazonaws.com", region)
+    if region == Region {
+        return client
     }
 
+    Region = region
+
     return newClientWithPersistentFlags()
 }
 
@@ -93,12 +193,30 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(
 		&Region,
 		"region",
-		"us-east-1",
-		"The region to use. Overrides config/env settings.")
+		"",
+		"The region to use. Overrides config/env settings. Falls back to the provider's region, then us-east-1.")
+
+	RootCmd.PersistentFlags().StringVar(
+		&AddressingStyle,
+		"addressing-style",
+		"",
+		"How buckets are addressed: path, virtual or auto. Defaults to the provider's style, or auto.")
+
+	RootCmd.PersistentFlags().StringVar(
+		&Provider,
+		"provider",
+		"",
+		"Preset defaults for an S3 compatible system: " + strings.Join(s3go.ProviderNames(), ", ") + ".")
+
+	RootCmd.PersistentFlags().StringVar(
+		&SignatureVersion,
+		"signature-version",
+		"",
+		"Request signing algorithm: v2 or v4. Defaults to the provider's version, or v4.")
 
 	RootCmd.PersistentFlags().BoolVar(
-		&VerifySSL,
+		&NoVerifySSL,
 		"no-verify-ssl",
 		false,
-		"This option overrides the default behavior of verifying SSL certificates.")
+		"This option overrides the default behavior of verifying SSL certificates. Requests are still made over HTTPS.")
 }
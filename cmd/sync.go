@@ -0,0 +1,84 @@
+package cmd
+
+import (
+    "github.com/spf13/cobra"
+    "github.com/scruwys/s3go/internal"
+)
+
+var syncCommand = &cobra.Command{
+    Use:   "sync",
+    Short: "Syncs directories and S3 prefixes. Only new or updated files and objects are copied.",
+    Args:  cobra.ExactArgs(2),
+    Run:   syncCommandHandler,
+}
+
+func syncCommandHandler(cmd *cobra.Command, args []string) {
+    source, target := parseTransferUrls(args)
+    encryption := encryptionFromFlags(source, target)
+    metadata, expires := parseMetadataFlags()
+    mimeTypes := loadMimeTypesFlag()
+
+    client := newClientForTransfer(source, target)
+    ctx := newCommandContext()
+
+    output, err := client.ListSyncObjects(ctx, &s3go.ListSyncObjectsInput{
+        SourceUrl:   source,
+        TargetUrl:   target,
+        Delete:      flagDelete,
+        CompareMode: compareModeFromFlags(),
+        Filters:     filtersFromFlags(),
+    })
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    progress := newProgress(true)
+    progress.Start()
+
+    summary := runTransferWorkers(ctx, client, &transferCommandWorkerInput{
+        objectCh:    progress.Track(ctx, output.Transfers),
+        progress:    progress,
+        encryption:  encryption,
+        metadata:    metadata,
+        expires:     expires,
+        mimeTypes:   mimeTypes,
+        source:      source,
+        target:      target,
+        deleteAfter: false,
+        recursive:   true,
+        desc:        syncDescription(source, target),
+    })
+
+    progress.Stop()
+
+    // Deletes are only listed once every transfer has been handed to a worker.
+    summary.Merge(runRemoveWorkers(ctx, client, nil, output.Deletes))
+
+    exitWithSummary(ctx, summary)
+}
+
+// Mirrors the aws cli, which labels each synced object by the direction it travelled.
+func syncDescription(source, target *s3go.S3Url) string {
+    if source.IsLocal() && !target.IsLocal() {
+        return "upload"
+    }
+
+    if !source.IsLocal() && target.IsLocal() {
+        return "download"
+    }
+
+    return "copy"
+}
+
+func init() {
+    addTransferFlags(syncCommand)
+
+    syncCommand.Flags().BoolVar(
+        &flagDelete,
+        "delete",
+        false,
+        "Files that exist in the destination but not in the source are deleted during sync.")
+
+    RootCmd.AddCommand(syncCommand)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+    "io/ioutil"
+    "os"
+    "testing"
+)
+
+func TestSyncUploadWithDelete(t *testing.T) {
+    dir := createTestDir(t, map[string]string{"a.txt": "alpha", "nested/b.txt": "bravo"})
+    defer os.RemoveAll(dir)
+
+    testServer.PutObject("sync-upload", "site/stale.txt", []byte("stale"))
+
+    output := runCommand(t, "sync", dir, "s3://sync-upload/site/", "--delete")
+
+    assertContains(t, output, "upload: "+dir+"/a.txt to s3://sync-upload/site/a.txt", "delete: s3://sync-upload/site/stale.txt")
+    assertKeys(t, "sync-upload", "site/a.txt", "site/nested/b.txt")
+
+    // Nothing has changed, so a second run has nothing to do.
+    if output := runCommand(t, "sync", dir, "s3://sync-upload/site/", "--delete"); output != "" {
+        t.Errorf("expected an up to date sync to be silent, got:\n%s", output)
+    }
+}
+
+func TestSyncDownloadWithFiltersAndPrefixWithoutSlash(t *testing.T) {
+    dir := createTestDir(t, map[string]string{"x.csv": "local", "stale.txt": "stale"})
+    defer os.RemoveAll(dir)
+
+    testServer.PutObject("sync-filters", "data/x.csv", []byte("remote"))
+    testServer.PutObject("sync-filters", "data/y.csv", []byte("yankee"))
+
+    // The exclude applies to data/x.csv in the bucket and x.csv locally alike.
+    output := runCommand(t, "sync", "s3://sync-filters/data", dir, "--delete", "--exclude", "x.csv")
+
+    assertContains(t, output, "download: s3://sync-filters/data/y.csv to "+dir+"/y.csv", "delete: "+dir+"/stale.txt")
+
+    if body, err := ioutil.ReadFile(dir + "/x.csv"); err != nil || string(body) != "local" {
+        t.Errorf("got %q (%v), want the excluded file left alone", body, err)
+    }
+
+    if _, err := os.Stat(dir + "/stale.txt"); !os.IsNotExist(err) {
+        t.Errorf("expected stale.txt to be deleted")
+    }
+}
+
+func TestSyncFailedListingDeletesNothing(t *testing.T) {
+    dir := createTestDir(t, map[string]string{"a.txt": "alpha"})
+    defer os.RemoveAll(dir)
+
+    testServer.PutObject("sync-unlistable", "data/a.txt", []byte("alpha"))
+    testServer.DenyListing("sync-unlistable")
+
+    output, code := runCommandWithExitCode(t, "sync", "s3://sync-unlistable/data/", dir, "--delete")
+
+    if code != 2 {
+        t.Errorf("got exit code %d, want 2", code)
+    }
+
+    assertContains(t, output, "Completed: 0 succeeded, 1 failed, 0 skipped.")
+
+    if _, err := os.Stat(dir + "/a.txt"); err != nil {
+        t.Errorf("expected the destination to be left alone after the source listing failed, got %v", err)
+    }
+}
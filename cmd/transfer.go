@@ -1,11 +1,61 @@
 package cmd
 
 import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
     "github.com/spf13/cobra"
     "github.com/scruwys/s3go/internal"
 )
 
 func transferCommandHandler(args []string, desc string, deleteAfter bool) {
+    source, target := parseTransferUrls(args)
+    validateVersionIdFlag(source)
+    encryption := encryptionFromFlags(source, target)
+    metadata, expires := parseMetadataFlags()
+    mimeTypes := loadMimeTypesFlag()
+
+    client := newClientForTransfer(source, target)
+    ctx := newCommandContext()
+
+    objectCh, err := client.ListSourceObjects(ctx, &s3go.ListSourceObjectsInput{
+        SourceUrl:   source,
+        Recursive:   flagRecursive,
+        Filters:     filtersFromFlags(),
+        VersionId:   flagVersionId,
+        CustomerKey: encryption.SourceCustomerKey,
+    })
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    progress := newProgress(true)
+    progress.Start()
+
+    summary := runTransferWorkers(ctx, client, &transferCommandWorkerInput{
+        objectCh:    progress.Track(ctx, exactObjects(ctx, objectCh, source)),
+        progress:    progress,
+        compareMode: compareModeFromFlags(),
+        encryption:  encryption,
+        metadata:    metadata,
+        expires:     expires,
+        mimeTypes:   mimeTypes,
+        source:      source,
+        target:      target,
+        deleteAfter: deleteAfter,
+        recursive:   flagRecursive,
+        desc:        desc,
+    })
+
+    progress.Stop()
+    exitWithSummary(ctx, summary)
+}
+
+// Parses the source and target arguments shared by cp, mv and sync.
+func parseTransferUrls(args []string) (*s3go.S3Url, *s3go.S3Url) {
     source, err := s3go.ParseUrl(args[0])
 
     if err != nil {
@@ -18,44 +68,175 @@ func transferCommandHandler(args []string, desc string, deleteAfter bool) {
         s3go.ExitWithError(1, err)
     }
 
-    bucket := target.Bucket
+    return source, target
+}
+
+// A version id names one version of one S3 object, so it can't be combined with a prefix or a local path.
+func validateVersionIdFlag(source *s3go.S3Url) {
+    if flagVersionId == "" {
+        return
+    }
+
+    if flagRecursive || source.IsLocal() {
+        s3go.ExitWithError(1, errors.New("--version-id can only be used with a single S3 object."))
+    }
+}
+
+// Builds the encryption options for a transfer. --sse-c is the key of the objects being written,
+// except for downloads, where it's the key of the objects being read.
+func encryptionFromFlags(source, target *s3go.S3Url) s3go.EncryptionOptions {
+    options := s3go.EncryptionOptions{
+        SSE:                  flagSSE,
+        KMSKeyId:             flagSSEKMSKeyId,
+        KMSEncryptionContext: flagSSEKMSContext,
+    }
+
+    customerKey := loadCustomerKeyFlag("--sse-c", flagSSEC)
+
+    if flagSSECCopySource != "" && (source.IsLocal() || target.IsLocal()) {
+        s3go.ExitWithError(1, errors.New("--sse-c-copy-source can only be used when copying between S3 locations."))
+    }
 
     if target.IsLocal() {
-        bucket = source.Bucket
+        options.SourceCustomerKey = customerKey
+    } else {
+        options.CustomerKey = customerKey
+        options.SourceCustomerKey = loadCustomerKeyFlag("--sse-c-copy-source", flagSSECCopySource)
     }
 
-    client := newClientWithRegionFromBucket(bucket)
-    doneCh := make(chan bool)
+    if err := s3go.ValidateEncryptionOptions(options); err != nil {
+        s3go.ExitWithError(1, err)
+    }
 
-    objectCh, err := client.ListSourceObjects(&s3go.ListSourceObjectsInput{
-        SourceUrl:     source,
-        Recursive:     flagRecursive,
-        IncludeFilter: flagIncludeFilter,
-        ExcludeFilter: flagExcludeFilter,
-    })
+    return options
+}
+
+// Parses and checks the flags that set the metadata of new objects.
+func parseMetadataFlags() (map[string]string, *time.Time) {
+    if err := s3go.ValidateMetadataDirective(flagMetadataDirective); err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    if err := s3go.ValidateTagging(flagTagging); err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    metadata, err := s3go.ParseMetadata(flagMetadata)
 
     if err != nil {
         s3go.ExitWithError(1, err)
     }
 
-    workerInput := &transferCommandWorkerInput{objectCh, doneCh, source, target, deleteAfter, desc}
-    workers := make([]<-chan s3go.ObjectInfo, flagConcurrency)
+    if flagExpires == "" {
+        return metadata, nil
+    }
+
+    expires, err := time.Parse(time.RFC3339, flagExpires)
 
-    for i := 0; i < flagConcurrency; i++ {
-        workers[i] = transferCommandWorker(client, workerInput)
+    if err != nil {
+        s3go.ExitWithError(1, fmt.Errorf("--expires must be an RFC 3339 timestamp, e.g. 2020-10-01T12:00:00Z: %v", err))
     }
 
-    for range s3go.MergeWaitWithObjectInfo(doneCh, workers...) {
-        continue
+    return metadata, &expires
+}
+
+// Loads the extension to content type mapping given by --mime-types, if any.
+func loadMimeTypesFlag() map[string]string {
+    if flagMimeTypes == "" {
+        return nil
+    }
+
+    types, err := s3go.LoadMimeTypes(flagMimeTypes)
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
     }
+
+    return types
+}
+
+// Loads the customer key named by a flag, if it was given.
+func loadCustomerKeyFlag(name, value string) []byte {
+    if value == "" {
+        return nil
+    }
+
+    key, err := s3go.LoadCustomerKey(value)
+
+    if err != nil {
+        s3go.ExitWithError(1, fmt.Errorf("%s: %v", name, err))
+    }
+
+    return key
+}
+
+// Builds a client whose region matches the bucket involved in a transfer.
+func newClientForTransfer(source, target *s3go.S3Url) *s3go.Client {
+    if sourceClientOptions() != nil {
+        return newClientWithSourceOptions(source, target)
+    }
+
+    // Local to local transfers never talk to S3, so there is no bucket region to look up.
+    if source.IsLocal() && target.IsLocal() {
+        return newClientWithPersistentFlags()
+    }
+
+    if target.IsLocal() {
+        return newClientWithRegionFromBucket(source.Bucket)
+    }
+
+    return newClientWithRegionFromBucket(target.Bucket)
+}
+
+// Builds a client for a transfer with --source-profile, --source-endpoint-url or --source-region.
+// The destination and source regions are each looked up with their own credentials.
+func newClientWithSourceOptions(source, target *s3go.S3Url) *s3go.Client {
+    if source.IsLocal() {
+        s3go.ExitWithError(1, errors.New("--source-profile, --source-endpoint-url and --source-region can only be used with an S3 source."))
+    }
+
+    var client *s3go.Client
+
+    if target.IsLocal() {
+        client = newClientWithPersistentFlags()
+    } else {
+        client = newClientWithRegionFromBucket(target.Bucket)
+    }
+
+    if flagSourceRegion != "" {
+        return client
+    }
+
+    region, err := client.GetSourceBucketRegion(source.Bucket)
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    flagSourceRegion = region
+
+    return newClientWithPersistentFlags()
+}
+
+// Fans the objects in input.objectCh out to flagConcurrency transfer workers and waits for them.
+func runTransferWorkers(ctx context.Context, client *s3go.Client, input *transferCommandWorkerInput) *s3go.ResultSummary {
+    input.ctx = ctx
+
+    workers := make([]<-chan s3go.ObjectResult, flagConcurrency)
+
+    for i := 0; i < flagConcurrency; i++ {
+        workers[i] = transferCommandWorker(client, input)
+    }
+
+    return collectResults(input.progress, workers...)
 }
 
 type transferCommandWorkerInput struct {
     // Channel
     objectCh <-chan s3go.ObjectInfo
 
-    // Channel
-    doneCh <-chan bool
+    // Cancelled when the command is interrupted
+    ctx context.Context
 
     // Docs
     source *s3go.S3Url
@@ -65,11 +246,28 @@ type transferCommandWorkerInput struct {
 
     deleteAfter bool
 
+    recursive bool
+
+    compareMode s3go.CompareMode
+
+    encryption s3go.EncryptionOptions
+
+    // Parsed --metadata and --expires
+    metadata map[string]string
+
+    expires *time.Time
+
+    // Extensions mapped to content types by --mime-types
+    mimeTypes map[string]string
+
     desc string
+
+    // Reports transferred bytes, may be nil
+    progress *s3go.Progress
 }
 
-func transferCommandWorker(client *s3go.Client, input *transferCommandWorkerInput) <-chan s3go.ObjectInfo {
-    resultCh := make(chan s3go.ObjectInfo)
+func transferCommandWorker(client *s3go.Client, input *transferCommandWorkerInput) <-chan s3go.ObjectResult {
+    resultCh := make(chan s3go.ObjectResult)
     // We append this to output when we are doing a dry run.
     dryRunPrefix := ""
 
@@ -80,42 +278,87 @@ func transferCommandWorker(client *s3go.Client, input *transferCommandWorkerInpu
     go func() {
         defer close(resultCh)
         for item := range input.objectCh {
-            select {
-                case <-input.doneCh:
-                    return
-                case resultCh <- item:
-                    if item.IsPrefix {
-                        continue
-                    }
-
-                    logMessage, err := client.MoveObject(item, &s3go.MoveObjectOptions{
-                        Target:             input.target,
-                        Source:             input.source,
-                        DeleteAfter:        input.deleteAfter,
-                        ACL:                flagACL,
-                        ContentDisposition: flagContentDisposition,
-                        ContentEncoding:    flagContentEncoding,
-                        ContentLanguage:    flagContentLanguage,
-                        ContentType:        flagContentType,
-                        DryRun:             flagDryRun,
-                        Recursive:          flagRecursive,
-                        RequestPayer:       flagRequestPayer,
-                    })
-
-                    if err != nil {
-                        s3go.Echo("%v", err)
-                    }
-
-                    if  err == nil && !flagQuiet && !flagOnlyShowErrors {
-                        s3go.Echo("%s%s: %s", dryRunPrefix, input.desc, logMessage)
-                    }
+            // Nothing new is started once the command has been interrupted.
+            if input.ctx.Err() != nil {
+                return
+            }
+
+            if item.Err != nil {
+                resultCh <- newListingFailedResult(item)
+                continue
+            }
+
+            if item.IsPrefix {
+                continue
             }
+
+            logMessage, err := client.MoveObject(input.ctx, item, &s3go.MoveObjectOptions{
+                Target:             input.target,
+                Source:             input.source,
+                DeleteAfter:        input.deleteAfter,
+                ACL:                flagACL,
+                ContentDisposition: flagContentDisposition,
+                ContentEncoding:    flagContentEncoding,
+                ContentLanguage:    flagContentLanguage,
+                ContentType:        flagContentType,
+                GuessMimeType:      !flagNoGuessMimeType,
+                SniffContentType:   flagGuessMimeTypeFromContent,
+                MimeTypes:          input.mimeTypes,
+                CacheControl:       flagCacheControl,
+                StorageClass:       flagStorageClass,
+                Metadata:           input.metadata,
+                MetadataDirective:  flagMetadataDirective,
+                Tagging:            flagTagging,
+                Expires:            input.expires,
+                WebsiteRedirect:    flagWebsiteRedirect,
+                CompareMode:        input.compareMode,
+                DryRun:             flagDryRun,
+                Recursive:          input.recursive,
+                RequestPayer:       flagRequestPayer,
+                Resume:             flagResume,
+                Encryption:         input.encryption,
+                Progress:           input.progress,
+            })
+
+            if err == s3go.ErrObjectUnchanged {
+                resultCh <- s3go.NewSkippedResult(item, "")
+                continue
+            }
+
+            resultCh <- newObjectResult(input.ctx, item, dryRunPrefix + input.desc + ": " + logMessage, input.desc + " failed: " + item.Url(), err)
         }
     }()
 
     return resultCh
 }
 
+// Picks the comparison mode from --size-only, --exact-timestamps and --checksum.
+func compareModeFromFlags() s3go.CompareMode {
+    modes := []s3go.CompareMode{}
+
+    if flagSizeOnly {
+        modes = append(modes, s3go.COMPARE_SIZE_ONLY)
+    }
+
+    if flagExactTimestamps {
+        modes = append(modes, s3go.COMPARE_EXACT_TIMESTAMPS)
+    }
+
+    if flagChecksum {
+        modes = append(modes, s3go.COMPARE_CHECKSUM)
+    }
+
+    if len(modes) > 1 {
+        s3go.ExitWithError(1, errors.New("Only one of --size-only, --exact-timestamps and --checksum may be used."))
+    }
+
+    if len(modes) == 0 {
+        return s3go.COMPARE_DEFAULT
+    }
+
+    return modes[0]
+}
+
 func addTransferFlags(command *cobra.Command) {
     command.Flags().BoolVar(
         &flagDryRun,
@@ -129,23 +372,7 @@ func addTransferFlags(command *cobra.Command) {
         false,
         "Does not display the operations performed from the specified command.")
 
-    command.Flags().BoolVar(
-        &flagRecursive,
-        "recursive",
-        false,
-        "Command is performed on all files or objects under the specified directory or prefix.")
-
-    command.Flags().StringVar(
-        &flagIncludeFilter,
-        "include",
-        "",
-        "Don't exclude files or objects in the command that match the specified pattern.")
-
-    command.Flags().StringVar(
-        &flagExcludeFilter,
-        "exclude",
-        "",
-        "Exclude all files or objects from the command that matches the specified pattern.")
+    addFilterFlags(command)
 
     command.Flags().StringVar(
         &flagRequestPayer,
@@ -189,6 +416,162 @@ func addTransferFlags(command *cobra.Command) {
         "",
         "Specify an explicit content type for this operation. This value overrides any guessed mime types.")
 
+    command.Flags().BoolVar(
+        &flagNoGuessMimeType,
+        "no-guess-mime-type",
+        false,
+        "Do not try to guess the mime type for uploaded files. By default it is guessed from the file extension.")
+
+    command.Flags().BoolVar(
+        &flagGuessMimeTypeFromContent,
+        "guess-mime-type-from-content",
+        false,
+        "Guess the mime type of uploaded files whose extension isn't known from their first 512 bytes.")
+
+    command.Flags().StringVar(
+        &flagMimeTypes,
+        "mime-types",
+        "",
+        "A file mapping extensions to mime types, in the mime.types format (e.g., \"text/markdown md markdown\" on each line). Takes precedence over the built-in mapping.")
+
+    command.Flags().StringVar(
+        &flagCacheControl,
+        "cache-control",
+        "",
+        "Specifies caching behavior along the request/reply chain.")
+
+    command.Flags().StringVar(
+        &flagExpires,
+        "expires",
+        "",
+        "The date and time at which the object is no longer cacheable, as an RFC 3339 timestamp (e.g., 2020-10-01T12:00:00Z).")
+
+    command.Flags().StringVar(
+        &flagWebsiteRedirect,
+        "website-redirect",
+        "",
+        "Redirects requests for the object to another object in the same bucket or to an external URL, if the bucket is configured as a website.")
+
+    command.Flags().StringVar(
+        &flagStorageClass,
+        "storage-class",
+        "",
+        "The storage class of new objects, e.g. STANDARD_IA or GLACIER. Defaults to STANDARD.")
+
+    command.Flags().StringVar(
+        &flagMetadata,
+        "metadata",
+        "",
+        "User-defined metadata for new objects, as comma separated key=value pairs.")
+
+    command.Flags().StringVar(
+        &flagMetadataDirective,
+        "metadata-directive",
+        "",
+        "Whether S3 to S3 copies keep the source's metadata and content headers (COPY, the default) or replace them with the ones given on the command line (REPLACE).")
+
+    command.Flags().StringVar(
+        &flagTagging,
+        "tagging",
+        "",
+        "Tags for new objects as URL encoded key=value pairs joined by & (e.g., team=data&env=prod). Copies keep the source's tags otherwise.")
+
+    command.Flags().BoolVar(
+        &flagSizeOnly,
+        "size-only",
+        false,
+        "Skips objects that already exist at the destination with the same size.")
+
+    command.Flags().BoolVar(
+        &flagExactTimestamps,
+        "exact-timestamps",
+        false,
+        "Skips objects that already exist at the destination with the same size and an identical timestamp.")
+
+    command.Flags().BoolVar(
+        &flagChecksum,
+        "checksum",
+        false,
+        "Skips objects that already exist at the destination with the same size and content hash (ETag).")
+
+    command.Flags().Int64Var(
+        &flagPartSize,
+        "multipart-chunksize",
+        0,
+        "The size in bytes of each part of a multipart upload or copy. Also used to calculate ETags for --checksum.")
+
+    command.Flags().Int64Var(
+        &flagMultipartThreshold,
+        "multipart-threshold",
+        0,
+        "Objects copied within S3 that are larger than this many bytes are copied in parts. Defaults to, and can't exceed, 5 GiB.")
+
+    command.Flags().IntVar(
+        &flagMultipartConcurrency,
+        "multipart-concurrency",
+        0,
+        "Number of parts of each object that are uploaded or copied in parallel. Defaults to 5.")
+
+    command.Flags().StringVar(
+        &flagSSE,
+        "sse",
+        "",
+        "Encrypts new objects with SSE-S3 (AES256) or SSE-KMS (aws:kms).")
+
+    command.Flags().StringVar(
+        &flagSSEKMSKeyId,
+        "sse-kms-key-id",
+        "",
+        "The KMS key to encrypt new objects with when using --sse aws:kms. Defaults to the AWS managed key.")
+
+    command.Flags().StringVar(
+        &flagSSEKMSContext,
+        "sse-kms-context",
+        "",
+        "Encryption context for --sse aws:kms, as a JSON object (e.g., {\"team\":\"data\"}).")
+
+    command.Flags().StringVar(
+        &flagSSEC,
+        "sse-c",
+        "",
+        "Customer provided key for SSE-C, as file:<path> or env:<name>. Encrypts uploads and copies, and decrypts downloads.")
+
+    command.Flags().StringVar(
+        &flagSSECCopySource,
+        "sse-c-copy-source",
+        "",
+        "Customer provided key the source objects of an S3 to S3 copy are encrypted with, as file:<path> or env:<name>.")
+
+    command.Flags().BoolVar(
+        &flagResume,
+        "resume",
+        false,
+        "Keeps unfinished downloads and continues them where they left off on the next run, as long as the object hasn't changed.")
+
+    command.Flags().StringVar(
+        &flagSourceProfile,
+        "source-profile",
+        "",
+        "Use a specific profile from your credential file for the source bucket, e.g. one in another account.")
+
+    command.Flags().StringVar(
+        &flagSourceEndpoint,
+        "source-endpoint-url",
+        "",
+        "Read the source bucket from this URL instead of the destination's endpoint.")
+
+    command.Flags().StringVar(
+        &flagSourceRegion,
+        "source-region",
+        "",
+        "The region of the source bucket. Looked up with the source credentials when omitted.")
+
+    command.Flags().BoolVar(
+        &flagNoProgress,
+        "no-progress",
+        false,
+        "File transfer progress is not displayed.")
+
     command.Flags().IntVar(
         &flagConcurrency,
         "concurrency",
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/spf13/cobra"
+    "github.com/scruwys/s3go/internal"
+)
+
+var undeleteCommand = &cobra.Command{
+	Use:   "undelete",
+	Short: "Restores every object under a prefix to the version that was current at a point in time.",
+    Args:  cobra.ExactArgs(1),
+	Run:   undeleteCommandHandler,
+}
+
+func undeleteCommandHandler(cmd *cobra.Command, args []string) {
+    uri, err := s3go.ParseUrl(args[0])
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    asOf, err := time.Parse(time.RFC3339, flagAsOf)
+
+    if err != nil {
+        s3go.ExitWithError(1, fmt.Errorf("--as-of must be an RFC 3339 timestamp, e.g. 2020-10-01T12:00:00Z: %v", err))
+    }
+
+    client := newClientWithRegionFromBucket(uri.Bucket)
+    ctx := newCommandContext()
+
+    restoreCh, err := client.ListVersionRestores(ctx, &s3go.ListObjectsV2Input{
+        Bucket:    uri.Bucket,
+        Prefix:    uri.Prefix,
+        Recursive: true,
+        Filters:   filtersFromFlags(),
+    }, asOf)
+
+    if err != nil {
+        s3go.ExitWithError(1, err)
+    }
+
+    progress := newProgress(false)
+    progress.Start()
+
+    options := &s3go.MoveObjectOptions{
+        DryRun:     flagDryRun,
+        Encryption: s3go.EncryptionOptions{SourceCustomerKey: loadCustomerKeyFlag("--sse-c-copy-source", flagSSECCopySource)},
+    }
+
+    workerInput := &undeleteCommandWorkerInput{trackRestores(ctx, progress, restoreCh), ctx, options}
+    workers := make([]<-chan s3go.ObjectResult, flagConcurrency)
+
+    for i := 0; i < flagConcurrency; i++ {
+        workers[i] = undeleteCommandWorker(client, workerInput)
+    }
+
+    summary := collectResults(progress, workers...)
+
+    progress.Stop()
+    exitWithSummary(ctx, summary)
+}
+
+// Counts every key that needs restoring towards the progress totals.
+func trackRestores(ctx context.Context, progress *s3go.Progress, restoreCh <-chan s3go.VersionRestore) <-chan s3go.VersionRestore {
+    if progress == nil {
+        return restoreCh
+    }
+
+    outputCh := make(chan s3go.VersionRestore)
+
+    go func() {
+        defer close(outputCh)
+
+        for restore := range restoreCh {
+            progress.AddTotal(0)
+
+            select {
+                case outputCh <- restore:
+                case <-ctx.Done():
+                    return
+            }
+        }
+
+        progress.ListingDone()
+    }()
+
+    return outputCh
+}
+
+type undeleteCommandWorkerInput struct {
+    // Keys to restore, with the version to restore them to
+    restoreCh <-chan s3go.VersionRestore
+
+    // Cancelled when the command is interrupted
+    ctx context.Context
+
+    // How versions are copied back
+    options *s3go.MoveObjectOptions
+}
+
+func undeleteCommandWorker(client *s3go.Client, input *undeleteCommandWorkerInput) <-chan s3go.ObjectResult {
+    resultCh := make(chan s3go.ObjectResult)
+
+    // We append this to output when we are doing a dry run.
+    dryRunPrefix := ""
+
+    if flagDryRun {
+        dryRunPrefix = "(dryrun) "
+    }
+
+    go func() {
+        defer close(resultCh)
+        for restore := range input.restoreCh {
+            // Nothing new is started once the command has been interrupted.
+            if input.ctx.Err() != nil {
+                return
+            }
+
+            item := restore.Version
+
+            if item.Err != nil {
+                resultCh <- newListingFailedResult(item)
+                continue
+            }
+
+            logMessage, err := client.RestoreVersion(input.ctx, restore, input.options)
+
+            resultCh <- newObjectResult(input.ctx, item, dryRunPrefix + "undelete: " + logMessage, "undelete failed: " + item.Url(), err)
+        }
+    }()
+    return resultCh
+}
+
+func init() {
+    undeleteCommand.Flags().StringVar(
+        &flagAsOf,
+        "as-of",
+        "",
+        "The point in time to restore to, as an RFC 3339 timestamp (e.g., 2020-10-01T12:00:00Z).")
+
+    undeleteCommand.Flags().BoolVar(
+        &flagDryRun,
+        "dryrun",
+        false,
+        "Displays the operations that would be performed using the specified command without actually running them.")
+
+    undeleteCommand.Flags().BoolVar(
+        &flagQuiet,
+        "quiet",
+        false,
+        "Does not display the operations performed from the specified command.")
+
+    addFilterFlags(undeleteCommand)
+
+    undeleteCommand.Flags().StringVar(
+        &flagSSECCopySource,
+        "sse-c-copy-source",
+        "",
+        "Customer provided key of versions encrypted with SSE-C, as file:<path> or env:<name>. They stay encrypted with it.")
+
+    undeleteCommand.Flags().BoolVar(
+        &flagNoProgress,
+        "no-progress",
+        false,
+        "Progress is not displayed.")
+
+    undeleteCommand.Flags().IntVar(
+        &flagConcurrency,
+        "concurrency",
+        1,
+        "Number of concurrent workers (e.g., goroutines) to spin up.")
+
+    undeleteCommand.MarkFlagRequired("as-of")
+
+	RootCmd.AddCommand(undeleteCommand)
+}
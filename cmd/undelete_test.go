@@ -0,0 +1,102 @@
+package cmd
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+// Creates a versioned bucket where, as of an hour ago, overwritten.txt said "before", deleted.txt
+// existed, unchanged.txt was as it is now and created.txt didn't exist yet.
+func createUndeleteBucket(t *testing.T, bucket string) {
+    t.Helper()
+
+    before := time.Now().UTC().Add(-2 * time.Hour)
+
+    testServer.EnableVersioning(bucket)
+
+    testServer.PutObject(bucket, "data/overwritten.txt", []byte("before")).LastModified = before
+    testServer.PutObject(bucket, "data/overwritten.txt", []byte("after"))
+
+    testServer.PutObject(bucket, "data/deleted.txt", []byte("deleted")).LastModified = before
+    testServer.DeleteObject(bucket, "data/deleted.txt")
+
+    testServer.PutObject(bucket, "data/unchanged.txt", []byte("unchanged")).LastModified = before
+    testServer.PutObject(bucket, "data/created.txt", []byte("created"))
+}
+
+func TestUndelete(t *testing.T) {
+    createUndeleteBucket(t, "undelete-bucket")
+
+    asOf := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+    output := runCommand(t, "undelete", "s3://undelete-bucket/data/", "--as-of", asOf)
+
+    assertContains(t, output, "undelete: s3://undelete-bucket/data/overwritten.txt", "undelete: s3://undelete-bucket/data/deleted.txt", "Completed: 2 succeeded")
+
+    // Keys that were created since are left alone.
+    assertKeys(t, "undelete-bucket", "data/created.txt", "data/deleted.txt", "data/overwritten.txt", "data/unchanged.txt")
+
+    if body := string(testServer.GetObject("undelete-bucket", "data/overwritten.txt").Body); body != "before" {
+        t.Errorf("got %s, want before", body)
+    }
+
+    // Deleting the delete marker brings the old version back without a copy.
+    if versions := testServer.Versions("undelete-bucket", "data/deleted.txt"); len(versions) != 1 {
+        t.Errorf("got %d versions of deleted.txt, want 1", len(versions))
+    }
+}
+
+func TestUndeleteDryRun(t *testing.T) {
+    createUndeleteBucket(t, "undelete-dryrun")
+
+    asOf := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+    output := runCommand(t, "undelete", "s3://undelete-dryrun/data/", "--as-of", asOf, "--dryrun")
+
+    assertContains(t, output, "(dryrun) undelete: s3://undelete-dryrun/data/overwritten.txt", "(dryrun) undelete: s3://undelete-dryrun/data/deleted.txt")
+    assertKeys(t, "undelete-dryrun", "data/created.txt", "data/overwritten.txt", "data/unchanged.txt")
+}
+
+func TestUndeleteKeepsStorageClassAndEncryption(t *testing.T) {
+    dir := createTestDir(t, map[string]string{
+        "secret.txt": "before",
+        "key.bin":    "0123456789abcdef0123456789abcdef",
+    })
+    defer os.RemoveAll(dir)
+
+    keyFlag := "file:" + filepath.Join(dir, "key.bin")
+    before := time.Now().UTC().Add(-2 * time.Hour)
+
+    testServer.EnableVersioning("undelete-settings")
+
+    archived := testServer.PutObject("undelete-settings", "kms/archived.txt", []byte("before"))
+    archived.LastModified = before
+    archived.Header.Set("Content-Type", "text/plain")
+    archived.Header.Set("X-Amz-Storage-Class", "STANDARD_IA")
+    archived.Header.Set("X-Amz-Server-Side-Encryption", "aws:kms")
+    archived.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", "arn:aws:kms:us-east-1:123456789012:key/abc")
+    testServer.PutObject("undelete-settings", "kms/archived.txt", []byte("after"))
+
+    runCommand(t, "cp", filepath.Join(dir, "secret.txt"), "s3://undelete-settings/ssec/secret.txt", "--sse-c", keyFlag)
+    testServer.GetObject("undelete-settings", "ssec/secret.txt").LastModified = before
+    testServer.PutObject("undelete-settings", "ssec/secret.txt", []byte("after"))
+
+    asOf := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+    runCommand(t, "undelete", "s3://undelete-settings/kms/", "--as-of", asOf)
+
+    // The customer key is sent for every version, so SSE-C objects are undeleted on their own.
+    runCommand(t, "undelete", "s3://undelete-settings/ssec/", "--as-of", asOf, "--sse-c-copy-source", keyFlag)
+
+    assertHeaders(t, "undelete-settings", "kms/archived.txt", map[string]string{
+        "Content-Type":                                "text/plain",
+        "X-Amz-Storage-Class":                         "STANDARD_IA",
+        "X-Amz-Server-Side-Encryption":                "aws:kms",
+        "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": "arn:aws:kms:us-east-1:123456789012:key/abc",
+    })
+
+    secret := testServer.GetObject("undelete-settings", "ssec/secret.txt")
+
+    if string(secret.Body) != "before" || secret.Header.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm") != "AES256" {
+        t.Errorf("got %q, want the old version still encrypted with the customer key", secret.Body)
+    }
+}
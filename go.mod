@@ -8,5 +8,7 @@ require (
 	github.com/minio/minio-go v6.0.14+incompatible
 	github.com/minio/minio-go/v6 v6.0.55
 	github.com/spf13/cobra v1.0.0
+	github.com/spf13/pflag v1.0.3
+	github.com/spf13/pflag v1.0.3
 	golang.org/x/tools/gopls v0.4.1 // indirect
 )
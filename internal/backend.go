@@ -0,0 +1,164 @@
+package s3go
+
+import (
+    "context"
+    "errors"
+    "io"
+)
+
+// A storage system that objects can be listed, read from and written to. The S3 API and the
+// local file system are both backends; anything else can be plugged in with RegisterBackend.
+type Backend interface {
+    // Lists the objects under the bucket and prefix described by the input.
+    List(ctx context.Context, input *ListObjectsV2Input) (<-chan ObjectInfo, error)
+
+    // Looks up a single object. Returns nil if it doesn't exist.
+    Stat(ctx context.Context, bucket, key string, options *MoveObjectOptions) (*ObjectInfo, error)
+
+    // Writes the contents of an object to w.
+    Get(ctx context.Context, object ObjectInfo, w io.WriterAt, options *MoveObjectOptions) error
+
+    // Stores the contents of r as a new object.
+    Put(ctx context.Context, bucket, key string, r io.Reader, options *MoveObjectOptions) error
+
+    // Copies an object to another location within the same backend.
+    Copy(ctx context.Context, object ObjectInfo, bucket, key string, options *MoveObjectOptions) error
+
+    // Deletes a single object.
+    Delete(ctx context.Context, object ObjectInfo, requestPayer string) error
+}
+
+// The most keys S3 accepts in a single DeleteObjects request.
+const DELETE_BATCH_SIZE = 1000
+
+// Implemented by backends that can delete many objects in a single request.
+type BatchDeleter interface {
+    // Deletes the objects, returning the error for each one that couldn't be deleted (nil otherwise).
+    DeleteBatch(ctx context.Context, objects []ObjectInfo, requestPayer string) []error
+}
+
+// Implemented by backends that can continue a download part way through an object.
+type RangeGetter interface {
+    // Writes the contents of an object from offset start onwards to w. Fails if the object's
+    // ETag no longer matches object.ETag.
+    GetRange(ctx context.Context, object ObjectInfo, w io.Writer, start int64, options *MoveObjectOptions) error
+}
+
+// Implemented by backends that keep content headers, metadata and tags with their objects, so that
+// streaming an object to another backend doesn't lose them.
+type MetadataGetter interface {
+    // The options to write a copy of the object with: options, with the object's content headers,
+    // metadata and tags filled in the way a copy within the backend would keep them.
+    GetMetadata(ctx context.Context, object ObjectInfo, options *MoveObjectOptions) (*MoveObjectOptions, error)
+}
+
+// Registers a backend for URLs with the given scheme, replacing any existing one.
+func(c *Client) RegisterBackend(scheme string, backend Backend) {
+    c.backends[scheme] = backend
+}
+
+// The backend responsible for objects under the given URL.
+func(c *Client) Backend(url *S3Url) Backend {
+    if backend, ok := c.backends[url.Scheme]; ok {
+        return backend
+    }
+    return c.backends[""]
+}
+
+// The backend that source objects under the given URL are read from. S3 sources use their own
+// backend when the client was configured with separate source options.
+func(c *Client) sourceBackend(url *S3Url) Backend {
+    if c.source != nil && !url.IsLocal() {
+        return c.source
+    }
+    return c.Backend(url)
+}
+
+// The backend an object was listed from. Objects that weren't listed through the client, e.g.
+// ones built by hand, are assumed to be local files or S3 objects.
+func(c *Client) objectBackend(object ObjectInfo) Backend {
+    if object.backend != nil {
+        return object.backend
+    }
+    if object.IsLocal() {
+        return c.backends[""]
+    }
+    return c.backends["s3"]
+}
+
+// Lists objects from backend, recording it on each one so deletes go back to the same backend.
+func listFrom(ctx context.Context, backend Backend, input *ListObjectsV2Input) (<-chan ObjectInfo, error) {
+    objectCh, err := backend.List(ctx, input)
+
+    if err != nil {
+        return nil, err
+    }
+
+    return withBackend(ctx, backend, objectCh), nil
+}
+
+func withBackend(ctx context.Context, backend Backend, objectCh <-chan ObjectInfo) <-chan ObjectInfo {
+    outputCh := make(chan ObjectInfo)
+
+    go func() {
+        defer close(outputCh)
+
+        for object := range objectCh {
+            object.backend = backend
+
+            if !sendObject(ctx, outputCh, object) {
+                return
+            }
+        }
+    }()
+
+    return outputCh
+}
+
+// Adapts an io.Writer to io.WriterAt for backends that can produce an object sequentially.
+// Anything that isn't written in order from offset zero is rejected.
+type sequentialWriterAt struct {
+    w io.Writer
+
+    offset int64
+}
+
+func (s *sequentialWriterAt) WriteAt(p []byte, off int64) (int, error) {
+    if off != s.offset {
+        return 0, errors.New("Attempted an out of order write to a sequential stream.")
+    }
+
+    n, err := s.w.Write(p)
+    s.offset += int64(n)
+
+    return n, err
+}
+
+// Stops reads from r once ctx is cancelled, so plain io.Copy loops can be interrupted.
+type contextReader struct {
+    ctx context.Context
+
+    r io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+    if err := c.ctx.Err(); err != nil {
+        return 0, err
+    }
+
+    return c.r.Read(p)
+}
+
+// Adapts an io.WriterAt to io.Writer by writing each chunk after the previous one.
+type offsetWriter struct {
+    w io.WriterAt
+
+    offset int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+    n, err := o.w.WriteAt(p, o.offset)
+    o.offset += int64(n)
+
+    return n, err
+}
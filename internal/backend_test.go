@@ -0,0 +1,304 @@
+package s3go
+
+import (
+    "bytes"
+    "context"
+    "io"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/scruwys/s3go/internal/s3test"
+)
+
+func TestSequentialWriterAt(t *testing.T) {
+    var buf bytes.Buffer
+    w := &sequentialWriterAt{w: &buf}
+
+    if _, err := w.WriteAt([]byte("hello "), 0); err != nil {
+        t.Fatal(err)
+    }
+
+    if _, err := w.WriteAt([]byte("world"), 6); err != nil {
+        t.Fatal(err)
+    }
+
+    if _, err := w.WriteAt([]byte("!"), 20); err == nil {
+        t.Errorf("out of order writes should be rejected")
+    }
+
+    if buf.String() != "hello world" {
+        t.Errorf("got %s, want hello world", buf.String())
+    }
+}
+
+func TestMoveObjectBetweenLocalPaths(t *testing.T) {
+    root, err := ioutil.TempDir("", "s3go-backend")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(root)
+
+    sourcePath := filepath.Join(root, "source", "nested", "file.txt")
+    targetDir := filepath.Join(root, "target") + "/"
+    writeTestFile(t, sourcePath, "local to local")
+
+    client := NewClient(&ClientOptions{})
+    backend := client.Backend(&S3Url{"", " ", sourcePath})
+
+    object, err := backend.Stat(context.Background(), "", sourcePath, &MoveObjectOptions{})
+    if err != nil || object == nil {
+        t.Fatalf("expected to stat %s: %v", sourcePath, err)
+    }
+
+    _, err = client.MoveObject(context.Background(), *object, &MoveObjectOptions{
+        Source:      &S3Url{"", " ", sourcePath},
+        Target:      &S3Url{"", " ", targetDir},
+        DeleteAfter: true,
+    })
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    body, err := ioutil.ReadFile(filepath.Join(targetDir, "file.txt"))
+    if err != nil || string(body) != "local to local" {
+        t.Errorf("got %q (%v), want the source contents", body, err)
+    }
+
+    if _, err := os.Stat(sourcePath); !os.IsNotExist(err) {
+        t.Errorf("the source should have been removed by the move")
+    }
+}
+
+func TestMoveObjectOntoItself(t *testing.T) {
+    root, err := ioutil.TempDir("", "s3go-backend")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(root)
+
+    sourcePath := filepath.Join(root, "file.txt")
+    linkPath := filepath.Join(root, "link.txt")
+    writeTestFile(t, sourcePath, "keep me")
+
+    if err := os.Link(sourcePath, linkPath); err != nil {
+        t.Fatal(err)
+    }
+
+    client := NewClient(&ClientOptions{})
+    object, err := client.Backend(&S3Url{"", " ", sourcePath}).Stat(context.Background(), "", sourcePath, &MoveObjectOptions{})
+    if err != nil || object == nil {
+        t.Fatalf("expected to stat %s: %v", sourcePath, err)
+    }
+
+    for _, target := range []string{sourcePath, filepath.Join(root, ".", "file.txt"), linkPath} {
+        _, err = client.MoveObject(context.Background(), *object, &MoveObjectOptions{
+            Source:      &S3Url{"", " ", sourcePath},
+            Target:      &S3Url{"", " ", target},
+            DeleteAfter: true,
+        })
+
+        if err != ErrSameFile {
+            t.Errorf("moving onto %s: got %v, want ErrSameFile", target, err)
+        }
+
+        body, err := ioutil.ReadFile(sourcePath)
+        if err != nil || string(body) != "keep me" {
+            t.Errorf("moving onto %s: got %q (%v), want the file left intact", target, body, err)
+        }
+    }
+}
+
+func TestMoveS3ObjectOntoItself(t *testing.T) {
+    server := s3test.NewServer()
+    defer server.Close()
+    defer useTestServer(t, server)()
+
+    server.CreateBucket("s3go-move")
+    server.PutObject("s3go-move", "a.txt", []byte("keep me"))
+
+    client := NewClient(&ClientOptions{Endpoint: server.URL, Region: "us-east-1", DisableSSL: true})
+    url := &S3Url{"s3", "s3go-move", "a.txt"}
+
+    object, err := client.Backend(url).Stat(context.Background(), "s3go-move", "a.txt", &MoveObjectOptions{})
+    if err != nil || object == nil {
+        t.Fatalf("expected to stat a.txt: %v", err)
+    }
+
+    // No compare mode is set, so the copy onto itself goes ahead and only the delete is skipped.
+    _, err = client.MoveObject(context.Background(), *object, &MoveObjectOptions{
+        Source:       url,
+        Target:       url,
+        StorageClass: "STANDARD_IA",
+        DeleteAfter:  true,
+    })
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    stored := server.GetObject("s3go-move", "a.txt")
+    if stored == nil || string(stored.Body) != "keep me" {
+        t.Fatal("expected the object to still exist after moving it onto itself")
+    }
+
+    if class := stored.Header.Get("X-Amz-Storage-Class"); class != "STANDARD_IA" {
+        t.Errorf("got storage class %q, want STANDARD_IA", class)
+    }
+}
+
+func TestLocalPutLeavesNoPartialFile(t *testing.T) {
+    root, err := ioutil.TempDir("", "s3go-backend")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(root)
+
+    target := filepath.Join(root, "file.txt")
+    writeTestFile(t, target, "old contents")
+
+    backend := NewLocalBackend()
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    if err := backend.Put(ctx, "", target, bytes.NewBufferString("new contents"), &MoveObjectOptions{}); err == nil {
+        t.Fatal("expected the cancelled write to fail")
+    }
+
+    if body, _ := ioutil.ReadFile(target); string(body) != "old contents" {
+        t.Errorf("got %q, want a failed write to leave the existing file alone", body)
+    }
+
+    if err := backend.Put(context.Background(), "", target, bytes.NewBufferString("new contents"), &MoveObjectOptions{}); err != nil {
+        t.Fatal(err)
+    }
+
+    if body, _ := ioutil.ReadFile(target); string(body) != "new contents" {
+        t.Errorf("got %q, want new contents", body)
+    }
+
+    files, _ := ioutil.ReadDir(root)
+    if len(files) != 1 {
+        t.Errorf("got %d files, want only the target without any partial file left behind", len(files))
+    }
+}
+
+// A backend that keeps objects in memory, standing in for one plugged in with RegisterBackend.
+type memoryBackend struct {
+    objects map[string]string
+}
+
+func (m *memoryBackend) List(ctx context.Context, input *ListObjectsV2Input) (<-chan ObjectInfo, error) {
+    ch := make(chan ObjectInfo, len(m.objects))
+    for key, body := range m.objects {
+        key, size := key, int64(len(body))
+        ch <- ObjectInfo{Bucket: &input.Bucket, Key: &key, Size: &size}
+    }
+    close(ch)
+    return ch, nil
+}
+
+func (m *memoryBackend) Stat(ctx context.Context, bucket, key string, options *MoveObjectOptions) (*ObjectInfo, error) {
+    return nil, nil
+}
+
+func (m *memoryBackend) Get(ctx context.Context, object ObjectInfo, w io.WriterAt, options *MoveObjectOptions) error {
+    _, err := w.WriteAt([]byte(m.objects[*object.Key]), 0)
+    return err
+}
+
+func (m *memoryBackend) Put(ctx context.Context, bucket, key string, r io.Reader, options *MoveObjectOptions) error {
+    body, err := ioutil.ReadAll(r)
+    m.objects[key] = string(body)
+    return err
+}
+
+func (m *memoryBackend) Copy(ctx context.Context, object ObjectInfo, bucket, key string, options *MoveObjectOptions) error {
+    m.objects[key] = m.objects[*object.Key]
+    return nil
+}
+
+func (m *memoryBackend) Delete(ctx context.Context, object ObjectInfo, requestPayer string) error {
+    delete(m.objects, *object.Key)
+    return nil
+}
+
+func TestRemoveObjectsFromRegisteredBackend(t *testing.T) {
+    backend := &memoryBackend{objects: map[string]string{"a.txt": "a", "b.txt": "b"}}
+
+    client := NewClient(&ClientOptions{Region: "us-east-1"})
+    client.RegisterBackend("mem", backend)
+
+    objectCh, err := client.ListSourceObjects(context.Background(), &ListSourceObjectsInput{
+        SourceUrl: &S3Url{"mem", "bucket", ""},
+        Recursive: true,
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    objects := []ObjectInfo{}
+    for object := range objectCh {
+        objects = append(objects, object)
+    }
+
+    for _, err := range client.RemoveObjects(context.Background(), objects, "") {
+        if err != nil {
+            t.Errorf("expected the delete to reach the registered backend: %v", err)
+        }
+    }
+
+    if len(backend.objects) != 0 {
+        t.Errorf("got %v left, want every object deleted", backend.objects)
+    }
+}
+
+// Produces size bytes, cancelling the context once cancelAt bytes have been read.
+type cancellingReader struct {
+    cancel func()
+
+    read, cancelAt, size int
+}
+
+func (r *cancellingReader) Read(p []byte) (int, error) {
+    if r.read >= r.size {
+        return 0, io.EOF
+    }
+
+    n := IntMin(len(p), r.size - r.read)
+    r.read += n
+
+    if r.read >= r.cancelAt {
+        r.cancel()
+    }
+
+    return n, nil
+}
+
+func TestPutAbortsMultipartUploadOnCancel(t *testing.T) {
+    server := s3test.NewServer()
+    defer server.Close()
+    defer useTestServer(t, server)()
+
+    server.CreateBucket("s3go-cancel")
+
+    client := NewClient(&ClientOptions{Endpoint: server.URL, Region: "us-east-1", DisableSSL: true})
+    ctx, cancel := context.WithCancel(context.Background())
+
+    body := &cancellingReader{cancel: cancel, cancelAt: 6 << 20, size: 16 << 20}
+    err := client.s3.Put(ctx, "s3go-cancel", "big.bin", body, &MoveObjectOptions{})
+
+    if err == nil {
+        t.Fatal("expected the cancelled upload to fail")
+    }
+
+    if pending := server.PendingUploads(); pending != 0 {
+        t.Errorf("got %d pending multipart uploads, want them all aborted", pending)
+    }
+
+    if server.GetObject("s3go-cancel", "big.bin") != nil {
+        t.Errorf("the cancelled upload should not have created an object")
+    }
+}
@@ -0,0 +1,131 @@
+package s3go
+
+import (
+    "crypto/md5"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "time"
+)
+
+// Controls how a source object is compared against an object that already exists at the destination.
+type CompareMode string
+
+const (
+    // cp and mv always transfer; sync transfers when the size differs or the source is newer.
+    COMPARE_DEFAULT CompareMode = ""
+
+    // Only the size of the objects is compared.
+    COMPARE_SIZE_ONLY CompareMode = "size-only"
+
+    // Objects are unchanged only if their size and timestamps match exactly.
+    COMPARE_EXACT_TIMESTAMPS CompareMode = "exact-timestamps"
+
+    // Objects are unchanged only if their size and content hash (ETag) match.
+    COMPARE_CHECKSUM CompareMode = "checksum"
+)
+
+// Returned by MoveObject when the destination already holds an identical copy of the object.
+var ErrObjectUnchanged = errors.New("The destination object is unchanged.")
+
+// Determines whether the source object differs from the target object using the given mode.
+func(c *Client) IsObjectModified(source, target ObjectInfo, mode CompareMode) (bool, error) {
+    if source.Size == nil || target.Size == nil || *source.Size != *target.Size {
+        return true, nil
+    }
+
+    switch mode {
+    case COMPARE_SIZE_ONLY:
+        return false, nil
+    case COMPARE_EXACT_TIMESTAMPS:
+        if source.LastModified == nil || target.LastModified == nil {
+            return true, nil
+        }
+        // S3 only stores timestamps to the second.
+        return !source.LastModified.Truncate(time.Second).Equal(target.LastModified.Truncate(time.Second)), nil
+    case COMPARE_CHECKSUM:
+        return c.isChecksumModified(source, target)
+    }
+
+    return isObjectModified(source, target), nil
+}
+
+// Compares ETags, calculating them for local files in the same format as the remote ETag.
+func(c *Client) isChecksumModified(source, target ObjectInfo) (bool, error) {
+    remote := source
+
+    if source.IsLocal() {
+        remote = target
+    }
+
+    if remote.IsLocal() || remote.ETag == nil {
+        return true, nil
+    }
+
+    remoteETag := strings.Trim(*remote.ETag, `"`)
+
+    if !source.IsLocal() && !target.IsLocal() {
+        return target.ETag == nil || strings.Trim(*target.ETag, `"`) != remoteETag, nil
+    }
+
+    local := target
+    if source.IsLocal() {
+        local = source
+    }
+
+    localETag, err := computeFileETag(*local.Key, c.s3.partSize(*local.Size), strings.Contains(remoteETag, "-"))
+
+    if err != nil {
+        return true, err
+    }
+
+    return localETag != remoteETag, nil
+}
+
+// Computes the MD5 of a file, or the ETag S3 assigns to a multipart upload of it:
+// the MD5 of the concatenated part MD5s, followed by the number of parts.
+func computeFileETag(path string, partSize int64, multipart bool) (string, error) {
+    file, err := os.Open(path)
+
+    if err != nil {
+        return "", err
+    }
+
+    defer file.Close()
+
+    if !multipart {
+        hash := md5.New()
+
+        if _, err := io.Copy(hash, file); err != nil {
+            return "", err
+        }
+
+        return hex.EncodeToString(hash.Sum(nil)), nil
+    }
+
+    digests := md5.New()
+    parts := 0
+
+    for {
+        hash := md5.New()
+        n, err := io.CopyN(hash, file, partSize)
+
+        if err != nil && err != io.EOF {
+            return "", err
+        }
+
+        if n > 0 {
+            digests.Write(hash.Sum(nil))
+            parts++
+        }
+
+        if err == io.EOF {
+            break
+        }
+    }
+
+    return fmt.Sprintf("%s-%d", hex.EncodeToString(digests.Sum(nil)), parts), nil
+}
@@ -0,0 +1,101 @@
+package s3go
+
+import (
+    "crypto/md5"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestComputeFileETag(t *testing.T) {
+    root, err := ioutil.TempDir("", "s3go-compare")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(root)
+
+    path := filepath.Join(root, "hello.txt")
+    writeTestFile(t, path, "hello")
+
+    etag, err := computeFileETag(path, 2, false)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if etag != "5d41402abc4b2a76b9719d911017c592" {
+        t.Errorf("got %s, want the plain MD5 of the file", etag)
+    }
+
+    etag, err = computeFileETag(path, 2, true)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    digests := []byte{}
+    for _, part := range []string{"he", "ll", "o"} {
+        sum := md5.Sum([]byte(part))
+        digests = append(digests, sum[:]...)
+    }
+    want := fmt.Sprintf("%x-3", md5.Sum(digests))
+
+    if etag != want {
+        t.Errorf("got %s, want %s", etag, want)
+    }
+}
+
+func TestClientIsObjectModified(t *testing.T) {
+    root, err := ioutil.TempDir("", "s3go-compare")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(root)
+
+    path := filepath.Join(root, "hello.txt")
+    writeTestFile(t, path, "hello")
+
+    emptyStr := ""
+    bucket := "s3go-testing"
+    size := int64(5)
+    older := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+    newer := older.Add(time.Hour)
+    matching := `"5d41402abc4b2a76b9719d911017c592"`
+    different := `"00000000000000000000000000000000"`
+
+    local := ObjectInfo{Bucket: &emptyStr, Key: &path, Size: &size, LastModified: &newer}
+
+    var tests = []struct {
+        name string
+        mode CompareMode
+        etag string
+        lastModified time.Time
+        want bool
+    }{
+        {"size-only ignores timestamps", COMPARE_SIZE_ONLY, different, older, false},
+        {"exact-timestamps requires equal times", COMPARE_EXACT_TIMESTAMPS, matching, older, true},
+        {"exact-timestamps with equal times", COMPARE_EXACT_TIMESTAMPS, different, newer, false},
+        {"checksum matches", COMPARE_CHECKSUM, matching, older, false},
+        {"checksum differs", COMPARE_CHECKSUM, different, newer, true},
+        {"default transfers newer sources", COMPARE_DEFAULT, matching, older, true},
+    }
+
+    client := NewClient(&ClientOptions{})
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            key := "hello.txt"
+            remote := ObjectInfo{Bucket: &bucket, Key: &key, Size: &size, ETag: &tt.etag, LastModified: &tt.lastModified}
+
+            ans, err := client.IsObjectModified(local, remote, tt.mode)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if ans != tt.want {
+                t.Errorf("got %v, want %v", ans, tt.want)
+            }
+        })
+    }
+}
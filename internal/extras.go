@@ -1,6 +1,7 @@
 package s3go
 
 import (
+    "context"
 	"fmt"
 	"os"
 	"sync"
@@ -9,7 +10,12 @@ import (
 )
 
 func Echo(format string, a ...interface{}) {
-	fmt.Println(fmt.Sprintf(format, a...))
+	writeLine(os.Stdout, fmt.Sprintf(format, a...))
+}
+
+// Like Echo, but for errors, which go to stderr so they aren't mixed in with piped output.
+func EchoError(format string, a ...interface{}) {
+	writeLine(os.Stderr, fmt.Sprintf(format, a...))
 }
 
 func ExitWithError(code int, err error) {
@@ -42,18 +48,15 @@ func ByteSizeToString(size int64, isflagHumanReadable bool) string {
 
 // https://medium.com/justforfunc/why-are-there-nil-channels-in-go-9877cc0b2308
 // https://github.com/jakewright/tutorials/blob/master/go/02-go-concurrency/05-fib.go
-func MergeWaitWithObjectInfo(done <-chan bool, channels ...<-chan ObjectInfo) <-chan ObjectInfo {
+// Results are always drained, even after cancellation, so that in-flight work is still reported.
+func MergeWaitWithObjectResult(channels ...<-chan ObjectResult) <-chan ObjectResult {
     var wg sync.WaitGroup
-    out := make(chan ObjectInfo)
+    out := make(chan ObjectResult)
 
-    multiplex := func(c <-chan ObjectInfo) {
+    multiplex := func(c <-chan ObjectResult) {
         defer wg.Done()
         for i := range c {
-            select {
-                case <-done:
-                    return
-                case out <- i:
-            }
+            out <- i
         }
     }
 
@@ -71,6 +74,65 @@ func MergeWaitWithObjectInfo(done <-chan bool, channels ...<-chan ObjectInfo) <-
     return out
 }
 
+// Sends an object unless ctx is cancelled first, in which case it returns false.
+func sendObject(ctx context.Context, ch chan<- ObjectInfo, object ObjectInfo) bool {
+    select {
+        case <-ctx.Done():
+            return false
+        case ch <- object:
+            return true
+    }
+}
+
+// Groups objects into batches of up to size, sending the last partial batch once objectCh closes.
+func BatchObjects(ctx context.Context, objectCh <-chan ObjectInfo, size int) <-chan []ObjectInfo {
+    batchCh := make(chan []ObjectInfo)
+
+    go func() {
+        defer close(batchCh)
+
+        batch := make([]ObjectInfo, 0, size)
+
+        send := func() bool {
+            select {
+                case <-ctx.Done():
+                    return false
+                case batchCh <- batch:
+                    batch = make([]ObjectInfo, 0, size)
+                    return true
+            }
+        }
+
+        for object := range objectCh {
+            // A failed listing is passed on in a batch of its own, so nothing tries to delete it.
+            if object.Err != nil {
+                if len(batch) > 0 && !send() {
+                    return
+                }
+
+                batch = append(batch, object)
+
+                if !send() {
+                    return
+                }
+                continue
+            }
+
+            batch = append(batch, object)
+
+            if len(batch) == size && !send() {
+                return
+            }
+        }
+
+        if len(batch) > 0 {
+            send()
+        }
+    }()
+
+    return batchCh
+}
+
 // Helper method to compile a regular expression with a default pattern (if none is provided)
 func RegexpCompile(pattern, defaultTo string) (*regexp.Regexp, error) {
     if pattern == "" {
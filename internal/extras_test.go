@@ -1,6 +1,7 @@
 package s3go
 
 import (
+    "context"
 	"fmt"
     "testing"
 )
@@ -104,3 +105,24 @@ func TestIntMin(t *testing.T) {
         })
     }
 }
+
+func TestBatchObjects(t *testing.T) {
+    objectCh := make(chan ObjectInfo)
+
+    go func() {
+        defer close(objectCh)
+        for i := 0; i < 7; i++ {
+            objectCh <- ObjectInfo{}
+        }
+    }()
+
+    sizes := []int{}
+
+    for batch := range BatchObjects(context.Background(), objectCh, 3) {
+        sizes = append(sizes, len(batch))
+    }
+
+    if fmt.Sprint(sizes) != "[3 3 1]" {
+        t.Errorf("got batches of %v, want [3 3 1]", sizes)
+    }
+}
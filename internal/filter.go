@@ -0,0 +1,188 @@
+package s3go
+
+import (
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// A single --include or --exclude rule.
+type FilterRule struct {
+    // Whether matching keys are excluded rather than included
+    Exclude bool
+
+    // Glob pattern, or a regular expression in regex mode
+    Pattern string
+}
+
+// Ordered --include and --exclude rules, compatible with the aws cli. Everything is included to
+// begin with, then each rule that matches a key includes or excludes it in turn, so later rules
+// win. The zero value includes everything.
+type Filters struct {
+    Rules []FilterRule
+
+    // Treat patterns as regular expressions matched anywhere in the full key or path, the way
+    // s3go used to. A key has to match an include rule, if there are any, and no exclude rule.
+    Regex bool
+}
+
+// Decides which keys or paths a listing keeps.
+type filterMatcher struct {
+    filters Filters
+
+    patterns []*regexp.Regexp
+
+    // Glob patterns are matched against what follows this, like the aws cli does
+    base string
+
+    local bool
+}
+
+// Compiles the rules for a listing of root, which is a bucket prefix or a local path. Globs are
+// matched relative to the directory being listed: the prefix up to its last "/" in S3, or the
+// path itself when it's a local directory.
+func newFilterMatcher(filters Filters, root string, local bool) (*filterMatcher, error) {
+    m := &filterMatcher{filters: filters, local: local}
+
+    for _, rule := range filters.Rules {
+        pattern := rule.Pattern
+
+        if !filters.Regex {
+            pattern = globToRegexp(pattern)
+        }
+
+        re, err := regexp.Compile(pattern)
+
+        if err != nil {
+            return nil, err
+        }
+
+        m.patterns = append(m.patterns, re)
+    }
+
+    if local {
+        if info, err := os.Stat(root); err == nil && info.IsDir() {
+            m.base = root
+        } else {
+            m.base = filepath.Dir(root)
+        }
+    } else {
+        m.base = root[:strings.LastIndex(root, "/") + 1]
+    }
+
+    return m, nil
+}
+
+// Treats an S3 prefix as a directory, so recursive listings of "data" and "data/" match globs
+// against the same relative keys as the local side of a sync does.
+func directoryPrefix(prefix string) string {
+    if prefix == "" || strings.HasSuffix(prefix, "/") {
+        return prefix
+    }
+    return prefix + "/"
+}
+
+// Whether the rules keep the key or path.
+func(m *filterMatcher) includes(path string) bool {
+    if m.filters.Regex {
+        return m.includesRegex(path)
+    }
+
+    relativePath := m.relativePath(path)
+    included := true
+
+    for i, rule := range m.filters.Rules {
+        if m.patterns[i].MatchString(relativePath) {
+            included = !rule.Exclude
+        }
+    }
+
+    return included
+}
+
+func(m *filterMatcher) includesRegex(path string) bool {
+    hasIncludes, included := false, false
+
+    for i, rule := range m.filters.Rules {
+        matches := m.patterns[i].MatchString(path)
+
+        if rule.Exclude && matches {
+            return false
+        }
+
+        if !rule.Exclude {
+            hasIncludes = true
+            included = included || matches
+        }
+    }
+
+    return included || !hasIncludes
+}
+
+func(m *filterMatcher) relativePath(path string) string {
+    if !m.local {
+        return strings.TrimPrefix(path, m.base)
+    }
+
+    relativePath, err := filepath.Rel(m.base, path)
+
+    if err != nil {
+        return filepath.ToSlash(path)
+    }
+
+    return filepath.ToSlash(relativePath)
+}
+
+// Translates a glob into an anchored regular expression. As with the aws cli, "*" also matches
+// "/", "?" matches any single character and "[...]" a character class, negated with "!".
+func globToRegexp(glob string) string {
+    var b strings.Builder
+    b.WriteString(`(?s)^`)
+
+    for i := 0; i < len(glob); i++ {
+        switch glob[i] {
+        case '*':
+            b.WriteString(".*")
+        case '?':
+            b.WriteString(".")
+        case '[':
+            // A "]" right after the opening bracket, or its "!", is part of the class.
+            j := i + 1
+            if j < len(glob) && glob[j] == '!' {
+                j++
+            }
+            if j < len(glob) && glob[j] == ']' {
+                j++
+            }
+
+            end := strings.IndexByte(glob[j:], ']')
+
+            if end < 0 {
+                b.WriteString(`\[`)
+                continue
+            }
+
+            class := glob[i+1 : j+end]
+            i = j + end
+            negate := strings.HasPrefix(class, "!")
+
+            if negate {
+                class = class[1:]
+            }
+
+            class = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "^", `\^`).Replace(class)
+
+            if negate {
+                class = "^" + class
+            }
+
+            b.WriteString("[" + class + "]")
+        default:
+            b.WriteString(regexp.QuoteMeta(glob[i:i+1]))
+        }
+    }
+
+    b.WriteString("$")
+    return b.String()
+}
@@ -0,0 +1,127 @@
+package s3go
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+    var tests = []struct {
+        glob  string
+        path  string
+        match bool
+    }{
+        {"*.csv", "report.csv", true},
+        {"*.csv", "2020/report.csv", true},
+        {"*.csv", "report.csv.gz", false},
+        {"logs/*", "logs/2020/a.log", true},
+        {"logs/*", "other/logs/a.log", false},
+        {"file?.txt", "file1.txt", true},
+        {"file?.txt", "file10.txt", false},
+        {"[ab].txt", "a.txt", true},
+        {"[!ab].txt", "a.txt", false},
+        {"[!ab].txt", "c.txt", true},
+        {"[]].txt", "].txt", true},
+        {"[^].txt", "^.txt", true},
+        {"a.b", "axb", false},
+        {"a+(b)", "a+(b)", true},
+        {"[unclosed", "[unclosed", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.glob + " " + tt.path, func(t *testing.T) {
+            m, err := newFilterMatcher(Filters{Rules: []FilterRule{{Pattern: tt.glob}}}, "", false)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := m.patterns[0].MatchString(tt.path); got != tt.match {
+                t.Errorf("got %v, want %v (regexp %s)", got, tt.match, globToRegexp(tt.glob))
+            }
+        })
+    }
+}
+
+func TestFilterMatcherOrder(t *testing.T) {
+    filters := Filters{Rules: []FilterRule{
+        {Exclude: true, Pattern: "*"},
+        {Exclude: false, Pattern: "*.csv"},
+        {Exclude: true, Pattern: "tmp/*"},
+    }}
+
+    m, err := newFilterMatcher(filters, "data/2020/", false)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    for key, want := range map[string]bool{
+        "data/2020/a.csv":     true,
+        "data/2020/b.json":    false,
+        "data/2020/tmp/c.csv": false,
+        "data/2020/x/d.csv":   true,
+    } {
+        if got := m.includes(key); got != want {
+            t.Errorf("%s: got %v, want %v", key, got, want)
+        }
+    }
+
+    // Patterns are relative to the prefix's directory, so "data/2020/rep" matches "report.csv".
+    m, _ = newFilterMatcher(Filters{Rules: []FilterRule{{Exclude: true, Pattern: "rep*"}}}, "data/2020/rep", false)
+
+    if m.includes("data/2020/report.csv") {
+        t.Error("got data/2020/report.csv included, want it excluded")
+    }
+}
+
+func TestFilterMatcherLocal(t *testing.T) {
+    dir, err := ioutil.TempDir("", "s3go-filter")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    writeTestFile(t, filepath.Join(dir, "nested", "a.txt"), "a")
+
+    filters := Filters{Rules: []FilterRule{{Exclude: true, Pattern: "nested/*"}}}
+
+    // Relative to the directory itself...
+    m, _ := newFilterMatcher(filters, dir, true)
+
+    if m.includes(filepath.Join(dir, "nested", "a.txt")) {
+        t.Error("got nested/a.txt included, want it excluded")
+    }
+
+    // ...or to the directory a single file is in.
+    m, _ = newFilterMatcher(Filters{Rules: []FilterRule{{Exclude: true, Pattern: "a.txt"}}}, filepath.Join(dir, "nested", "a.txt"), true)
+
+    if m.includes(filepath.Join(dir, "nested", "a.txt")) {
+        t.Error("got a.txt included, want it excluded")
+    }
+}
+
+func TestFilterMatcherRegex(t *testing.T) {
+    filters := Filters{Regex: true, Rules: []FilterRule{
+        {Exclude: false, Pattern: `\.csv$`},
+        {Exclude: true, Pattern: `^data/tmp/`},
+    }}
+
+    m, err := newFilterMatcher(filters, "data/", false)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    for key, want := range map[string]bool{
+        "data/a.csv":     true,
+        "data/b.json":    false,
+        "data/tmp/c.csv": false,
+    } {
+        if got := m.includes(key); got != want {
+            t.Errorf("%s: got %v, want %v", key, got, want)
+        }
+    }
+
+    if _, err = newFilterMatcher(Filters{Regex: true, Rules: []FilterRule{{Pattern: "("}}}, "", false); err == nil {
+        t.Error("got no error, want the invalid regex to be rejected")
+    }
+}
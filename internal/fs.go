@@ -1,10 +1,15 @@
 package s3go
 
 import (
+    "context"
     "path/filepath"
     "errors"
     "fmt"
+    "io/ioutil"
 	"os"
+    "strings"
+
+    "github.com/aws/aws-sdk-go/aws"
 )
 
 func pathExists(path string) (error) {
@@ -17,7 +22,11 @@ func pathExists(path string) (error) {
 }
 
 func ensureDir(dirName string) error {
-    err := os.Mkdir(dirName, 0700)
+    if dirName == "" {
+        return nil
+    }
+
+    err := os.MkdirAll(dirName, 0700)
 
     if err == nil || os.IsExist(err) {
         return nil
@@ -26,39 +35,118 @@ func ensureDir(dirName string) error {
     }
 }
 
-func createFile(path string) (*os.File, error) {
-    dir, _ := filepath.Split(path)
+// Whether two local paths refer to the same file, including through symlinks or hard links.
+func sameFile(a, b string) bool {
+    if filepath.Clean(a) == filepath.Clean(b) {
+        return true
+    }
 
-    if err := ensureDir(dir); err != nil {
-        return nil, err
+    aInfo, err := os.Stat(a)
+
+    if err != nil {
+        return false
+    }
+
+    bInfo, err := os.Stat(b)
+
+    if err != nil {
+        return false
     }
 
-    return os.Create(path)
+    return os.SameFile(aInfo, bInfo)
 }
 
-func listFiles(rootPath string, recursive bool, excludeFilter string, includeFilter string) (ch <-chan ObjectInfo, err error) {
-    outputCh := make(chan ObjectInfo)
+// Suffix of the hidden files that downloads are written to before being renamed into place.
+const PARTIAL_DOWNLOAD_SUFFIX = ".s3go-partial"
 
-    excludeRe, err := RegexpCompile(excludeFilter, "$^")
-    if err != nil {
-        return nil, err
+// The file a download of object to path is written to until it's complete. It's named after the
+// object's ETag, so a resumed download never appends to a partial copy of a different object.
+func partialDownloadPath(path string, object ObjectInfo) string {
+    dir, name := filepath.Split(path)
+    etag := strings.Trim(aws.StringValue(object.ETag), `"`)
+
+    if etag == "" {
+        etag = "download"
     }
 
-    includeRe, err := RegexpCompile(includeFilter, ".*")
+    return filepath.Join(dir, "." + name + "." + etag + PARTIAL_DOWNLOAD_SUFFIX)
+}
+
+// Whether a local path is an unfinished download.
+func isPartialDownload(path string) bool {
+    return strings.HasSuffix(path, PARTIAL_DOWNLOAD_SUFFIX)
+}
+
+// Removes partial downloads of earlier versions of the object at path, keeping the one at keep.
+func removeStalePartialDownloads(path, keep string) {
+    dir, name := filepath.Split(path)
+    files, err := ioutil.ReadDir(filepath.Join(dir, "."))
+
     if err != nil {
-        return nil, err
+        return
     }
 
+    prefix := "." + name + "."
+
+    for _, file := range files {
+        candidate := filepath.Join(dir, file.Name())
+
+        if !strings.HasPrefix(file.Name(), prefix) || !isPartialDownload(candidate) || candidate == keep {
+            continue
+        }
+
+        // ETags never contain dots, so anything else belongs to another file, e.g. data.csv
+        // rather than data.
+        etag := strings.TrimSuffix(strings.TrimPrefix(file.Name(), prefix), PARTIAL_DOWNLOAD_SUFFIX)
+
+        if etag != "" && !strings.Contains(etag, ".") {
+            os.Remove(candidate)
+        }
+    }
+}
+
+// Normalizes a local source path so it lines up with the paths produced by filepath.Walk.
+func cleanLocalPrefix(path string) string {
+    path = filepath.Clean(path)
+
+    if path == "." {
+        return ""
+    }
+
+    return strings.TrimRight(path, "/") + "/"
+}
+
+func listFiles(ctx context.Context, rootPath string, recursive bool, filters Filters) (ch <-chan ObjectInfo, err error) {
+    outputCh := make(chan ObjectInfo)
+
     if err := pathExists(rootPath); err != nil {
         return nil, err
     }
 
+    filter, err := newFilterMatcher(filters, rootPath, true)
+    if err != nil {
+        return nil, err
+    }
+
     go func() {
         defer close(outputCh)
 
-        filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-            // We ignore anything that doesn't pass the filter checks
-            if excludeRe.MatchString(path) || !includeRe.MatchString(path) {
+        err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+            if ctx.Err() != nil {
+                return ctx.Err()
+            }
+
+            if err != nil {
+                return err
+            }
+
+            // We ignore any file that doesn't pass the filter checks
+            if !info.IsDir() && !filter.includes(path) {
+                return nil
+            }
+
+            // Unfinished downloads aren't real files yet.
+            if !info.IsDir() && isPartialDownload(path) {
                 return nil
             }
 
@@ -74,17 +162,27 @@ func listFiles(rootPath string, recursive bool, excludeFilter string, includeFil
             // If it's not a directory, it's a file, so let's process it
             if !info.IsDir() {
                 emptyStr := ""
+                size := info.Size()
+                modTime := info.ModTime()
 
                 objectInfo := ObjectInfo{
-                    Bucket:   &emptyStr,
-                    Key:      &path,
-                    IsPrefix: false,
+                    Bucket:       &emptyStr,
+                    Key:          &path,
+                    IsPrefix:     false,
+                    Size:         &size,
+                    LastModified: &modTime,
+                }
+                if !sendObject(ctx, outputCh, objectInfo) {
+                    return ctx.Err()
                 }
-                outputCh <- objectInfo
             }
 
             return nil
         })
+
+        if err != nil && ctx.Err() == nil {
+            sendObject(ctx, outputCh, listingError("", rootPath, err))
+        }
     }()
 
     return outputCh, nil
@@ -0,0 +1,107 @@
+package s3go
+
+import (
+    "context"
+    "errors"
+    "io"
+    "os"
+    "path/filepath"
+)
+
+// Returned when a local file would be copied or moved onto itself.
+var ErrSameFile = errors.New("The source and destination are the same file.")
+
+// Backend for files on the local file system. Buckets are ignored and keys are file paths.
+type LocalBackend struct {}
+
+func NewLocalBackend() *LocalBackend {
+    return &LocalBackend{}
+}
+
+func(b *LocalBackend) List(ctx context.Context, input *ListObjectsV2Input) (<-chan ObjectInfo, error) {
+    return listFiles(ctx, input.Prefix, input.Recursive, input.Filters)
+}
+
+func(b *LocalBackend) Stat(ctx context.Context, bucket, key string, options *MoveObjectOptions) (*ObjectInfo, error) {
+    info, err := os.Stat(key)
+
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+
+    if err != nil {
+        return nil, err
+    }
+
+    emptyStr := ""
+    size := info.Size()
+    modTime := info.ModTime()
+
+    return &ObjectInfo{Bucket: &emptyStr, Key: &key, Size: &size, LastModified: &modTime}, nil
+}
+
+func(b *LocalBackend) Get(ctx context.Context, object ObjectInfo, w io.WriterAt, options *MoveObjectOptions) error {
+    file, err := os.Open(*object.Key)
+
+    if err != nil {
+        return err
+    }
+
+    defer file.Close()
+
+    _, err = io.Copy(&offsetWriter{w: w}, &contextReader{ctx, file})
+    return err
+}
+
+// Writes to a partial file next to key and renames it into place once complete, so a failed
+// write never leaves a truncated file behind.
+func(b *LocalBackend) Put(ctx context.Context, bucket, key string, r io.Reader, options *MoveObjectOptions) error {
+    dir, _ := filepath.Split(key)
+
+    if err := ensureDir(dir); err != nil {
+        return err
+    }
+
+    partialPath := partialDownloadPath(key, ObjectInfo{})
+    file, err := os.OpenFile(partialPath, os.O_WRONLY | os.O_CREATE | os.O_TRUNC, 0666)
+
+    if err != nil {
+        return err
+    }
+
+    _, err = io.Copy(file, &contextReader{ctx, r})
+
+    if closeErr := file.Close(); err == nil {
+        err = closeErr
+    }
+
+    if err == nil {
+        err = os.Rename(partialPath, key)
+    }
+
+    if err != nil {
+        os.Remove(partialPath)
+    }
+
+    return err
+}
+
+func(b *LocalBackend) Copy(ctx context.Context, object ObjectInfo, bucket, key string, options *MoveObjectOptions) error {
+    if sameFile(*object.Key, key) {
+        return ErrSameFile
+    }
+
+    file, err := os.Open(*object.Key)
+
+    if err != nil {
+        return err
+    }
+
+    defer file.Close()
+
+    return b.Put(ctx, bucket, key, file, options)
+}
+
+func(b *LocalBackend) Delete(ctx context.Context, object ObjectInfo, requestPayer string) error {
+    return os.Remove(*object.Key)
+}
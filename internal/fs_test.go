@@ -1 +1,78 @@
 package s3go
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestCleanLocalPrefix(t *testing.T) {
+    var tests = []struct {
+        path, want string
+    }{
+        {".", ""},
+        {"./", ""},
+        {"./data", "data/"},
+        {"./data/", "data/"},
+        {"data//nested/", "data/nested/"},
+        {"/tmp/data", "/tmp/data/"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.path, func(t *testing.T) {
+            ans := cleanLocalPrefix(tt.path)
+            if ans != tt.want {
+                t.Errorf("got %s, want %s", ans, tt.want)
+            }
+        })
+    }
+}
+
+func TestPartialDownloadPath(t *testing.T) {
+    etag := `"5d41402abc4b2a76b9719d911017c592"`
+    object := ObjectInfo{ETag: &etag}
+
+    if got, want := partialDownloadPath("data/report.csv", object), "data/.report.csv.5d41402abc4b2a76b9719d911017c592.s3go-partial"; got != want {
+        t.Errorf("got %s, want %s", got, want)
+    }
+
+    if got, want := partialDownloadPath("report.csv", ObjectInfo{}), ".report.csv.download.s3go-partial"; got != want {
+        t.Errorf("got %s, want %s", got, want)
+    }
+
+    if !isPartialDownload(partialDownloadPath("report.csv", object)) || isPartialDownload("report.csv") {
+        t.Errorf("expected only the partial download to be recognised")
+    }
+}
+
+func TestRemoveStalePartialDownloads(t *testing.T) {
+    root, err := ioutil.TempDir("", "s3go-partial")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(root)
+
+    oldEtag, newEtag, otherEtag := "old", "new", "other"
+    target := filepath.Join(root, "data")
+
+    stale := partialDownloadPath(target, ObjectInfo{ETag: &oldEtag})
+    keep := partialDownloadPath(target, ObjectInfo{ETag: &newEtag})
+    other := partialDownloadPath(filepath.Join(root, "data.csv"), ObjectInfo{ETag: &otherEtag})
+
+    for _, path := range []string{stale, keep, other} {
+        writeTestFile(t, path, "partial")
+    }
+
+    removeStalePartialDownloads(target, keep)
+
+    if _, err := os.Stat(stale); !os.IsNotExist(err) {
+        t.Errorf("expected %s to be removed", stale)
+    }
+
+    for _, path := range []string{keep, other} {
+        if _, err := os.Stat(path); err != nil {
+            t.Errorf("expected %s to be kept: %v", path, err)
+        }
+    }
+}
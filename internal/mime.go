@@ -0,0 +1,108 @@
+package s3go
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "mime"
+    "net/http"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// http.DetectContentType never looks past the first 512 bytes.
+const MIME_SNIFF_SIZE = 512
+
+// Reads a mapping of file extensions to content types in the mime.types format used by Apache
+// and nginx, i.e. a type followed by its extensions on each line:
+//
+//     text/markdown       md markdown
+//     application/wasm    wasm
+//
+// Blank lines and lines starting with # are ignored.
+func LoadMimeTypes(path string) (map[string]string, error) {
+    file, err := os.Open(path)
+
+    if err != nil {
+        return nil, err
+    }
+
+    defer file.Close()
+
+    types := make(map[string]string)
+    scanner := bufio.NewScanner(file)
+
+    for line := 1; scanner.Scan(); line++ {
+        fields := strings.Fields(scanner.Text())
+
+        if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+            continue
+        }
+
+        if len(fields) < 2 || !strings.Contains(fields[0], "/") {
+            return nil, fmt.Errorf("%s:%d: Expected a content type followed by one or more extensions.", path, line)
+        }
+
+        for _, ext := range fields[1:] {
+            types["." + strings.ToLower(strings.TrimPrefix(ext, "."))] = fields[0]
+        }
+    }
+
+    if err = scanner.Err(); err != nil {
+        return nil, err
+    }
+
+    return types, nil
+}
+
+// Guesses the content type of a file from its extension, looking in the user's mapping before the
+// system's. If neither knows it and content is given, the first 512 bytes are sniffed instead.
+// Returns "" when there is nothing to go on.
+func guessContentType(path string, types map[string]string, content io.ReaderAt) string {
+    ext := strings.ToLower(filepath.Ext(path))
+
+    if ext != "" {
+        if contentType, ok := types[ext]; ok {
+            return contentType
+        }
+
+        if contentType := mime.TypeByExtension(ext); contentType != "" {
+            return contentType
+        }
+    }
+
+    if content == nil {
+        return ""
+    }
+
+    buffer := make([]byte, MIME_SNIFF_SIZE)
+    n, err := content.ReadAt(buffer, 0)
+
+    if n == 0 || (err != nil && err != io.EOF) {
+        return ""
+    }
+
+    return http.DetectContentType(buffer[:n])
+}
+
+// The options an upload is made with, with the content type filled in when it has to be guessed.
+func withGuessedContentType(options *MoveObjectOptions, path string, content io.ReaderAt) *MoveObjectOptions {
+    if options.ContentType != "" || !options.GuessMimeType {
+        return options
+    }
+
+    if !options.SniffContentType {
+        content = nil
+    }
+
+    contentType := guessContentType(path, options.MimeTypes, content)
+
+    if contentType == "" {
+        return options
+    }
+
+    guessed := *options
+    guessed.ContentType = contentType
+    return &guessed
+}
@@ -0,0 +1,64 @@
+package s3go
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestLoadMimeTypes(t *testing.T) {
+    dir, err := ioutil.TempDir("", "s3go-mime")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    path := filepath.Join(dir, "mime.types")
+    ioutil.WriteFile(path, []byte("# overrides\n\ntext/markdown  md .Markdown\napplication/wasm wasm\n"), 0644)
+
+    types, err := LoadMimeTypes(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    want := map[string]string{".md": "text/markdown", ".markdown": "text/markdown", ".wasm": "application/wasm"}
+
+    if !reflect.DeepEqual(types, want) {
+        t.Errorf("got %v, want %v", types, want)
+    }
+
+    ioutil.WriteFile(path, []byte("md text/markdown\n"), 0644)
+
+    if _, err = LoadMimeTypes(path); err == nil {
+        t.Error("got no error, want the reversed line to be rejected")
+    }
+}
+
+func TestGuessContentType(t *testing.T) {
+    var tests = []struct {
+        name    string
+        path    string
+        types   map[string]string
+        content string
+        want    string
+    }{
+        {"extension", "site/index.HTML", nil, "", "text/html; charset=utf-8"},
+        {"override", "README.md", map[string]string{".md": "text/markdown"}, "", "text/markdown"},
+        {"override wins", "app.js", map[string]string{".js": "text/plain"}, "", "text/plain"},
+        {"sniffed", "Makefile", nil, "all:\n\tgo build\n", "text/plain; charset=utf-8"},
+        {"sniffed binary", "blob", nil, "\x89PNG\r\n\x1a\n", "image/png"},
+        {"empty", "empty", nil, "", ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := guessContentType(tt.path, tt.types, strings.NewReader(tt.content))
+            if got != tt.want {
+                t.Errorf("got %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
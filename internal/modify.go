@@ -0,0 +1,157 @@
+package s3go
+
+import (
+    "context"
+    "net/http"
+    "strings"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Changes the storage class, metadata, content headers, tags or encryption of an object in place by
+// copying it over itself. Anything options doesn't set is kept, see copyInPlace.
+func(c *Client) ModifyObject(ctx context.Context, object ObjectInfo, options *MoveObjectOptions) (string, error) {
+    logMessage := object.Url()
+
+    if options.DryRun {
+        return logMessage, nil
+    }
+
+    if err := c.copyInPlace(ctx, object, options); err != nil {
+        return "", err
+    }
+
+    // Copies happen in one go, so the whole object counts once it's done.
+    if options.Progress != nil && object.Size != nil {
+        options.Progress.newTracker().add(0, *object.Size)
+    }
+
+    return logMessage, nil
+}
+
+// Copies an object, or one of its versions, over the current version of its key with the changes in
+// options. Anything options doesn't set is read from the object first and kept, so e.g. changing the
+// storage class doesn't lose the Content-Type. Without options.ACL the object's grants are put back
+// after the copy. Objects encrypted with a customer key need Encryption.SourceCustomerKey, and stay
+// encrypted with it unless new encryption is given.
+func(c *Client) copyInPlace(ctx context.Context, object ObjectInfo, options *MoveObjectOptions) error {
+    algorithm, customerKey := customerKeyParams(options.Encryption.SourceCustomerKey)
+
+    current, err := c.s3.svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+        Bucket:               object.Bucket,
+        Key:                  object.Key,
+        VersionId:            object.VersionId,
+        RequestPayer:         nonEmptyString(options.RequestPayer),
+        SSECustomerAlgorithm: algorithm,
+        SSECustomerKey:       customerKey,
+    })
+
+    if err != nil {
+        return err
+    }
+
+    // A copy always gets a private ACL unless a canned one is given.
+    var acl *s3.GetObjectAclOutput
+
+    if options.ACL == "" {
+        acl, err = c.s3.svc.GetObjectAclWithContext(ctx, &s3.GetObjectAclInput{
+            Bucket:       object.Bucket,
+            Key:          object.Key,
+            VersionId:    object.VersionId,
+            RequestPayer: nonEmptyString(options.RequestPayer),
+        })
+
+        if err != nil {
+            return err
+        }
+    }
+
+    if err = c.s3.Copy(ctx, object, *object.Bucket, *object.Key, mergeModifyOptions(current, options)); err != nil {
+        return err
+    }
+
+    if acl == nil || isPrivateAcl(acl) {
+        return nil
+    }
+
+    _, err = c.s3.svc.PutObjectAclWithContext(ctx, &s3.PutObjectAclInput{
+        Bucket:              object.Bucket,
+        Key:                 object.Key,
+        RequestPayer:        nonEmptyString(options.RequestPayer),
+        AccessControlPolicy: &s3.AccessControlPolicy{Owner: acl.Owner, Grants: acl.Grants},
+    })
+
+    return err
+}
+
+// Whether the ACL only gives the owner full control, which is what a copy ends up with anyway.
+// Skipping those also keeps modify working on buckets that have ACLs disabled.
+func isPrivateAcl(acl *s3.GetObjectAclOutput) bool {
+    if len(acl.Grants) != 1 || acl.Owner == nil || acl.Grants[0].Grantee == nil {
+        return false
+    }
+
+    grant := acl.Grants[0]
+
+    return aws.StringValue(grant.Permission) == s3.PermissionFullControl &&
+        aws.StringValue(grant.Grantee.ID) == aws.StringValue(acl.Owner.ID)
+}
+
+// The options to copy an object over itself with: the changes in options on top of what the
+// object already has. The metadata directive is always REPLACE, since S3 refuses a copy onto
+// itself that changes nothing, and COPY would ignore new headers.
+func mergeModifyOptions(current *s3.HeadObjectOutput, options *MoveObjectOptions) *MoveObjectOptions {
+    merged := *options
+    merged.MetadataDirective = METADATA_DIRECTIVE_REPLACE
+
+    keep := func(value *string, existing *string) {
+        if *value == "" {
+            *value = aws.StringValue(existing)
+        }
+    }
+
+    keep(&merged.CacheControl, current.CacheControl)
+    keep(&merged.ContentDisposition, current.ContentDisposition)
+    keep(&merged.ContentEncoding, current.ContentEncoding)
+    keep(&merged.ContentLanguage, current.ContentLanguage)
+    keep(&merged.ContentType, current.ContentType)
+    keep(&merged.StorageClass, current.StorageClass)
+    keep(&merged.WebsiteRedirect, current.WebsiteRedirectLocation)
+
+    if merged.Expires == nil && current.Expires != nil {
+        if expires, err := http.ParseTime(*current.Expires); err == nil {
+            merged.Expires = &expires
+        }
+    }
+
+    // New metadata is added to the object's, replacing values with the same key. S3 stores keys
+    // in lower case, while the SDK hands them back canonicalized.
+    merged.Metadata = make(map[string]string)
+
+    for key, value := range current.Metadata {
+        merged.Metadata[strings.ToLower(key)] = aws.StringValue(value)
+    }
+
+    for key, value := range options.Metadata {
+        merged.Metadata[strings.ToLower(key)] = value
+    }
+
+    // Without new encryption, the object keeps what it has. S3 never returns the KMS encryption
+    // context though, so that is lost unless it's given again.
+    if merged.Encryption.SSE == "" && len(merged.Encryption.CustomerKey) == 0 {
+        if len(merged.Encryption.SourceCustomerKey) > 0 {
+            merged.Encryption.CustomerKey = merged.Encryption.SourceCustomerKey
+        } else {
+            merged.Encryption.SSE = aws.StringValue(current.ServerSideEncryption)
+            merged.Encryption.KMSKeyId = aws.StringValue(current.SSEKMSKeyId)
+        }
+    }
+
+    // Giving SSE-KMS again, e.g. to set the encryption context, keeps the current key.
+    if merged.Encryption.SSE == SSE_KMS && merged.Encryption.KMSKeyId == "" && aws.StringValue(current.ServerSideEncryption) == SSE_KMS {
+        merged.Encryption.KMSKeyId = aws.StringValue(current.SSEKMSKeyId)
+    }
+
+    return &merged
+}
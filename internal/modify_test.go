@@ -0,0 +1,59 @@
+package s3go
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestMergeModifyOptions(t *testing.T) {
+    current := &s3.HeadObjectOutput{
+        ContentType:          aws.String("text/csv"),
+        StorageClass:         aws.String("STANDARD_IA"),
+        Expires:              aws.String("Wed, 02 Jan 2030 03:04:05 GMT"),
+        Metadata:             aws.StringMap(map[string]string{"Owner": "data", "Build": "41"}),
+        ServerSideEncryption: aws.String(SSE_KMS),
+        SSEKMSKeyId:          aws.String("arn:aws:kms:us-east-1:123456789012:key/abc"),
+    }
+
+    merged := mergeModifyOptions(current, &MoveObjectOptions{ContentType: "application/csv", Metadata: map[string]string{"build": "42"}})
+
+    if merged.MetadataDirective != METADATA_DIRECTIVE_REPLACE {
+        t.Errorf("got directive %q, want REPLACE", merged.MetadataDirective)
+    }
+
+    if merged.ContentType != "application/csv" || merged.StorageClass != "STANDARD_IA" || merged.Expires == nil || merged.Expires.Year() != 2030 {
+        t.Errorf("got %+v, want the new content type and the current storage class and expiry", merged)
+    }
+
+    if want := map[string]string{"owner": "data", "build": "42"}; !reflect.DeepEqual(merged.Metadata, want) {
+        t.Errorf("got metadata %v, want %v", merged.Metadata, want)
+    }
+
+    if merged.Encryption.SSE != SSE_KMS || merged.Encryption.KMSKeyId != *current.SSEKMSKeyId {
+        t.Errorf("got encryption %+v, want the current KMS key", merged.Encryption)
+    }
+
+    // New encryption replaces the current one, and customer keys are kept when nothing else is given.
+    merged = mergeModifyOptions(current, &MoveObjectOptions{Encryption: EncryptionOptions{SSE: SSE_AES256}})
+
+    if merged.Encryption.SSE != SSE_AES256 || merged.Encryption.KMSKeyId != "" {
+        t.Errorf("got encryption %+v, want SSE-S3", merged.Encryption)
+    }
+
+    // Giving SSE-KMS again with a context keeps the current key.
+    merged = mergeModifyOptions(current, &MoveObjectOptions{Encryption: EncryptionOptions{SSE: SSE_KMS, KMSEncryptionContext: `{"team":"data"}`}})
+
+    if merged.Encryption.KMSKeyId != *current.SSEKMSKeyId || merged.Encryption.KMSEncryptionContext == "" {
+        t.Errorf("got encryption %+v, want the current KMS key with the new context", merged.Encryption)
+    }
+
+    key := []byte("0123456789abcdef0123456789abcdef")
+    merged = mergeModifyOptions(&s3.HeadObjectOutput{}, &MoveObjectOptions{Encryption: EncryptionOptions{SourceCustomerKey: key}})
+
+    if string(merged.Encryption.CustomerKey) != string(key) || merged.Encryption.SSE != "" {
+        t.Errorf("got encryption %+v, want the same customer key", merged.Encryption)
+    }
+}
@@ -0,0 +1,430 @@
+package s3go
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "os"
+    "sort"
+    "sync"
+    "time"
+)
+
+// Serializes everything written to the terminal, so that Echo and the progress line never interleave.
+var outputMu sync.Mutex
+
+// The progress reporter currently drawing a live line, if any. Guarded by outputMu.
+var activeProgress *Progress
+
+// Reports aggregate progress for a command that works on many objects. On a terminal it redraws a
+// single status line; anywhere else it writes a log line every interval.
+type Progress struct {
+    out io.Writer
+
+    // Whether out is a terminal that the status line can be redrawn on
+    tty bool
+
+    // Whether the command moves data, as opposed to e.g. rm where only objects are counted
+    countBytes bool
+
+    // How often the status is refreshed
+    interval time.Duration
+
+    mu sync.Mutex
+
+    start time.Time
+
+    bytes int64
+
+    totalBytes int64
+
+    objects int
+
+    totalObjects int
+
+    // Set once the listing has finished and the totals are final
+    listed bool
+
+    stopCh chan struct{}
+
+    doneCh chan struct{}
+
+    // Used instead of time.Now by tests
+    now func() time.Time
+}
+
+func NewProgress(out io.Writer, tty, countBytes bool) *Progress {
+    interval := 10 * time.Second
+
+    if tty {
+        interval = 250 * time.Millisecond
+    }
+
+    return &Progress{
+        out:        out,
+        tty:        tty,
+        countBytes: countBytes,
+        interval:   interval,
+        now:        time.Now,
+    }
+}
+
+// Whether f is a terminal, as opposed to a pipe or a regular file.
+func IsTerminal(f *os.File) bool {
+    info, err := f.Stat()
+    return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Starts refreshing the status in the background until Stop is called. Like the other methods,
+// it does nothing on a nil Progress, so callers don't need to check whether progress is enabled.
+func(p *Progress) Start() {
+    if p == nil {
+        return
+    }
+
+    p.mu.Lock()
+    p.start = p.now()
+    p.mu.Unlock()
+
+    p.stopCh = make(chan struct{})
+    p.doneCh = make(chan struct{})
+
+    if p.tty {
+        outputMu.Lock()
+        activeProgress = p
+        outputMu.Unlock()
+    }
+
+    go func() {
+        defer close(p.doneCh)
+
+        ticker := time.NewTicker(p.interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+                case <-p.stopCh:
+                    return
+                case <-ticker.C:
+                    p.render()
+            }
+        }
+    }()
+}
+
+// Stops refreshing and clears the status line, leaving the terminal as it was.
+func(p *Progress) Stop() {
+    if p == nil || p.stopCh == nil {
+        return
+    }
+
+    close(p.stopCh)
+    <-p.doneCh
+
+    outputMu.Lock()
+    defer outputMu.Unlock()
+
+    if activeProgress == p {
+        activeProgress = nil
+        p.clearLine()
+    }
+}
+
+// Counts an object found by the listing towards the totals.
+func(p *Progress) AddTotal(size int64) {
+    if p == nil {
+        return
+    }
+
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    p.totalObjects++
+    p.totalBytes += size
+}
+
+// Marks the totals as final.
+func(p *Progress) ListingDone() {
+    if p == nil {
+        return
+    }
+
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    p.listed = true
+}
+
+// Counts the totals of every object that passes through the channel, marking them final once it closes.
+func(p *Progress) Track(ctx context.Context, objectCh <-chan ObjectInfo) <-chan ObjectInfo {
+    if p == nil {
+        return objectCh
+    }
+
+    outputCh := make(chan ObjectInfo)
+
+    go func() {
+        defer close(outputCh)
+
+        for object := range objectCh {
+            if !object.IsPrefix {
+                size := int64(0)
+                if object.Size != nil {
+                    size = *object.Size
+                }
+                p.AddTotal(size)
+            }
+
+            if !sendObject(ctx, outputCh, object) {
+                return
+            }
+        }
+
+        p.ListingDone()
+    }()
+
+    return outputCh
+}
+
+// Records the outcome of an object. Skipped objects never move any data, so they come off the total.
+func(p *Progress) ObjectDone(result ObjectResult) {
+    if p == nil {
+        return
+    }
+
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    p.objects++
+
+    if result.Status == RESULT_SKIPPED && result.Object.Size != nil {
+        p.totalBytes -= *result.Object.Size
+    }
+}
+
+func(p *Progress) addBytes(n int64) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    p.bytes += n
+}
+
+// The current status, e.g. "Completed 1.5 MiB/3.0 MiB (512.0 KiB/s) with 2 of 4 object(s) done, ETA 3s"
+func(p *Progress) String() string {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    objects := fmt.Sprintf("%d object(s) done, still listing", p.objects)
+
+    if p.listed {
+        objects = fmt.Sprintf("%d of %d object(s) done", p.objects, p.totalObjects)
+    }
+
+    if !p.countBytes {
+        return "Completed " + objects
+    }
+
+    elapsed := p.now().Sub(p.start).Seconds()
+    rate := float64(0)
+
+    if elapsed > 0 {
+        rate = float64(p.bytes) / elapsed
+    }
+
+    line := fmt.Sprintf("Completed %s (%s/s) with %s", HumanizeBytes(p.bytes), HumanizeBytes(int64(rate)), objects)
+
+    if p.listed {
+        line = fmt.Sprintf("Completed %s/%s (%s/s) with %s", HumanizeBytes(p.bytes), HumanizeBytes(p.totalBytes), HumanizeBytes(int64(rate)), objects)
+
+        if remaining := p.totalBytes - p.bytes; rate > 0 && remaining > 0 {
+            eta := time.Duration(float64(remaining) / rate * float64(time.Second))
+            line += ", ETA " + eta.Round(time.Second).String()
+        }
+    }
+
+    return line
+}
+
+func(p *Progress) render() {
+    line := p.String()
+
+    outputMu.Lock()
+    defer outputMu.Unlock()
+
+    if p.tty {
+        fmt.Fprint(p.out, "\r\033[K" + line)
+    } else {
+        fmt.Fprintln(p.out, line)
+    }
+}
+
+// Must be called with outputMu held.
+func(p *Progress) clearLine() {
+    fmt.Fprint(p.out, "\r\033[K")
+}
+
+// Writes a line of output, moving the live status line out of the way first.
+func writeLine(w io.Writer, line string) {
+    outputMu.Lock()
+    defer outputMu.Unlock()
+
+    if activeProgress != nil {
+        activeProgress.clearLine()
+    }
+
+    fmt.Fprintln(w, line)
+
+    if activeProgress != nil {
+        fmt.Fprint(activeProgress.out, activeProgress.String())
+    }
+}
+
+// Counts the bytes of a single object as they are read or written. Parts of an object can be read
+// more than once, e.g. to sign them before they are sent, so only the first read of a byte counts.
+type progressTracker struct {
+    progress *Progress
+
+    mu sync.Mutex
+
+    // Sorted, non-overlapping ranges of the object that have been counted so far
+    ranges [][2]int64
+}
+
+func(p *Progress) newTracker() *progressTracker {
+    if p == nil {
+        return nil
+    }
+    return &progressTracker{progress: p}
+}
+
+// Counts the range [start, end), returning how many of its bytes hadn't been counted before.
+func(t *progressTracker) add(start, end int64) int64 {
+    if t == nil || end <= start {
+        return 0
+    }
+
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    added := end - start
+    merged := [2]int64{start, end}
+    ranges := make([][2]int64, 0, len(t.ranges) + 1)
+
+    for _, r := range t.ranges {
+        if r[1] < merged[0] || r[0] > merged[1] {
+            ranges = append(ranges, r)
+            continue
+        }
+
+        // Whatever the existing range already covered isn't new.
+        overlap := minInt64(r[1], end) - maxInt64(r[0], start)
+        if overlap > 0 {
+            added -= overlap
+        }
+
+        merged = [2]int64{minInt64(r[0], merged[0]), maxInt64(r[1], merged[1])}
+    }
+
+    ranges = append(ranges, merged)
+    sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+    t.ranges = ranges
+
+    t.progress.addBytes(added)
+
+    return added
+}
+
+// Wraps a file being uploaded. It stays an io.ReaderAt and io.Seeker so the uploader can still
+// read parts concurrently and knows the size up front.
+type progressReader struct {
+    file *os.File
+
+    tracker *progressTracker
+
+    offset int64
+}
+
+func(r *progressReader) Read(p []byte) (int, error) {
+    n, err := r.file.Read(p)
+    r.tracker.add(r.offset, r.offset + int64(n))
+    r.offset += int64(n)
+
+    return n, err
+}
+
+func(r *progressReader) ReadAt(p []byte, off int64) (int, error) {
+    n, err := r.file.ReadAt(p, off)
+    r.tracker.add(off, off + int64(n))
+
+    return n, err
+}
+
+func(r *progressReader) Seek(offset int64, whence int) (int64, error) {
+    position, err := r.file.Seek(offset, whence)
+    r.offset = position
+
+    return position, err
+}
+
+// Wraps the destination of a download.
+type progressWriterAt struct {
+    w io.WriterAt
+
+    tracker *progressTracker
+}
+
+func(w *progressWriterAt) WriteAt(p []byte, off int64) (int, error) {
+    n, err := w.w.WriteAt(p, off)
+    w.tracker.add(off, off + int64(n))
+
+    return n, err
+}
+
+// Wraps a stream, e.g. the pipe between two backends.
+type progressStreamReader struct {
+    r io.Reader
+
+    tracker *progressTracker
+
+    offset int64
+}
+
+func(r *progressStreamReader) Read(p []byte) (int, error) {
+    n, err := r.r.Read(p)
+    r.tracker.add(r.offset, r.offset + int64(n))
+    r.offset += int64(n)
+
+    return n, err
+}
+
+// Wraps the destination of a stream, e.g. stdout.
+type progressStreamWriter struct {
+    w io.Writer
+
+    tracker *progressTracker
+
+    offset int64
+}
+
+func(w *progressStreamWriter) Write(p []byte) (int, error) {
+    n, err := w.w.Write(p)
+    w.tracker.add(w.offset, w.offset + int64(n))
+    w.offset += int64(n)
+
+    return n, err
+}
+
+func minInt64(a, b int64) int64 {
+    if a < b {
+        return a
+    }
+    return b
+}
+
+func maxInt64(a, b int64) int64 {
+    if a > b {
+        return a
+    }
+    return b
+}
+
@@ -0,0 +1,153 @@
+package s3go
+
+import (
+    "bytes"
+    "context"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/scruwys/s3go/internal/s3test"
+)
+
+func TestProgressTrackerCountsBytesOnce(t *testing.T) {
+    progress := NewProgress(&bytes.Buffer{}, false, true)
+    tracker := progress.newTracker()
+
+    var tests = []struct {
+        start, end int64
+        want       int64
+    }{
+        {0, 10, 10},
+        {0, 10, 0},
+        {20, 30, 10},
+        {5, 25, 10},
+        {30, 40, 10},
+        {0, 40, 0},
+    }
+
+    for _, tt := range tests {
+        if got := tracker.add(tt.start, tt.end); got != tt.want {
+            t.Errorf("add(%d, %d): got %d, want %d", tt.start, tt.end, got, tt.want)
+        }
+    }
+
+    if progress.bytes != 40 {
+        t.Errorf("got %d bytes, want 40", progress.bytes)
+    }
+}
+
+func TestProgressString(t *testing.T) {
+    start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+    progress := NewProgress(&bytes.Buffer{}, false, true)
+    progress.now = func() time.Time { return start }
+    progress.Start()
+    progress.Stop()
+
+    progress.AddTotal(4096)
+    progress.AddTotal(4096)
+    progress.newTracker().add(0, 2048)
+    progress.now = func() time.Time { return start.Add(2 * time.Second) }
+
+    if got := progress.String(); got != "Completed 2.0 KiB (1.0 KiB/s) with 0 object(s) done, still listing" {
+        t.Errorf("got %q", got)
+    }
+
+    progress.ListingDone()
+    progress.ObjectDone(NewSucceededResult(ObjectInfo{}, ""))
+
+    if got := progress.String(); got != "Completed 2.0 KiB/8.0 KiB (1.0 KiB/s) with 1 of 2 object(s) done, ETA 6s" {
+        t.Errorf("got %q", got)
+    }
+
+    objectsOnly := NewProgress(&bytes.Buffer{}, false, false)
+    objectsOnly.AddTotal(10)
+    objectsOnly.ListingDone()
+
+    if got := objectsOnly.String(); got != "Completed 0 of 1 object(s) done" {
+        t.Errorf("got %q", got)
+    }
+}
+
+func TestProgressTrack(t *testing.T) {
+    progress := NewProgress(&bytes.Buffer{}, false, true)
+    objectCh := make(chan ObjectInfo, 3)
+
+    size := int64(5)
+    objectCh <- ObjectInfo{Size: &size}
+    objectCh <- ObjectInfo{Size: &size}
+    objectCh <- ObjectInfo{Size: &size, IsPrefix: true}
+    close(objectCh)
+
+    for range progress.Track(context.Background(), objectCh) {
+        continue
+    }
+
+    if progress.totalObjects != 2 || progress.totalBytes != 10 || !progress.listed {
+        t.Errorf("got %d objects and %d bytes (listed=%v), want 2 and 10", progress.totalObjects, progress.totalBytes, progress.listed)
+    }
+}
+
+func TestWriteLineRedrawsProgress(t *testing.T) {
+    var status, output bytes.Buffer
+
+    progress := NewProgress(&status, true, false)
+    progress.Start()
+
+    writeLine(&output, "delete: s3://bucket/a.txt")
+    progress.Stop()
+
+    if output.String() != "delete: s3://bucket/a.txt\n" {
+        t.Errorf("got %q", output.String())
+    }
+
+    if !strings.Contains(status.String(), "Completed 0 object(s) done") || !strings.HasSuffix(status.String(), "\r\033[K") {
+        t.Errorf("expected the status line to be redrawn and then cleared, got %q", status.String())
+    }
+
+    // Nothing should be left drawing once the progress has stopped.
+    if activeProgress != nil {
+        t.Errorf("expected no active progress")
+    }
+}
+
+func TestUploadProgressCountsEachByteOnce(t *testing.T) {
+    server := s3test.NewServer()
+    defer server.Close()
+    defer useTestServer(t, server)()
+
+    server.CreateBucket("s3go-progress")
+
+    root, err := ioutil.TempDir("", "s3go-progress")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(root)
+
+    path := filepath.Join(root, "file.txt")
+    writeTestFile(t, path, strings.Repeat("s3go", 1024))
+
+    client := NewClient(&ClientOptions{Endpoint: server.URL, Region: "us-east-1", DisableSSL: true})
+    progress := NewProgress(&bytes.Buffer{}, false, true)
+
+    object, _ := client.Backend(&S3Url{}).Stat(context.Background(), "", path, &MoveObjectOptions{})
+
+    _, err = client.MoveObject(context.Background(), *object, &MoveObjectOptions{
+        Source:   &S3Url{"", "", path},
+        Target:   &S3Url{"s3", "s3go-progress", "file.txt"},
+        Progress: progress,
+    })
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // The body is read once to sign the request and again to send it.
+    if progress.bytes != 4096 {
+        t.Errorf("got %d bytes, want 4096", progress.bytes)
+    }
+}
@@ -0,0 +1,170 @@
+package s3go
+
+import (
+    "fmt"
+    "net/url"
+    "sort"
+    "strings"
+)
+
+const (
+    ADDRESSING_STYLE_AUTO    = "auto"
+    ADDRESSING_STYLE_PATH    = "path"
+    ADDRESSING_STYLE_VIRTUAL = "virtual"
+
+    SIGNATURE_VERSION_V2 = "v2"
+    SIGNATURE_VERSION_V4 = "v4"
+
+    // Used when neither the flags, the provider nor the shared config name a region.
+    DEFAULT_REGION = "us-east-1"
+)
+
+// Defaults for an S3 compatible storage system. Anything set explicitly on the client wins.
+type Provider struct {
+    // How buckets are addressed: path, virtual or auto
+    AddressingStyle string
+
+    // Request signing algorithm: v2 or v4
+    SignatureVersion string
+
+    // Region to sign requests for when none is given
+    Region string
+
+    // Endpoint template for providers with a well-known URL. %s is replaced with the region.
+    Endpoint string
+
+    // Whether --endpoint-url has to be given because there is no well-known URL
+    RequiresEndpoint bool
+}
+
+var providers = map[string]Provider{
+    "aws": {
+        AddressingStyle:  ADDRESSING_STYLE_AUTO,
+        SignatureVersion: SIGNATURE_VERSION_V4,
+    },
+    "minio": {
+        AddressingStyle:  ADDRESSING_STYLE_PATH,
+        SignatureVersion: SIGNATURE_VERSION_V4,
+        Region:           DEFAULT_REGION,
+        RequiresEndpoint: true,
+    },
+    // Current RGW releases accept v4. Older ones can still be reached with --signature-version v2.
+    "ceph": {
+        AddressingStyle:  ADDRESSING_STYLE_PATH,
+        SignatureVersion: SIGNATURE_VERSION_V4,
+        Region:           DEFAULT_REGION,
+        RequiresEndpoint: true,
+    },
+    "wasabi": {
+        AddressingStyle:  ADDRESSING_STYLE_VIRTUAL,
+        SignatureVersion: SIGNATURE_VERSION_V4,
+        Region:           DEFAULT_REGION,
+        Endpoint:         "https://s3.%s.wasabisys.com",
+    },
+    "generic": {
+        AddressingStyle:  ADDRESSING_STYLE_PATH,
+        SignatureVersion: SIGNATURE_VERSION_V4,
+        RequiresEndpoint: true,
+    },
+}
+
+// Names of the known providers, sorted.
+func ProviderNames() []string {
+    names := make([]string, 0, len(providers))
+
+    for name := range providers {
+        names = append(names, name)
+    }
+
+    sort.Strings(names)
+    return names
+}
+
+// Checks the addressing style, signature version and provider before a client is built.
+func ValidateClientOptions(options *ClientOptions) error {
+    provider, ok := providers[strings.ToLower(options.Provider)]
+
+    if options.Provider != "" && !ok {
+        return fmt.Errorf("Unknown provider %q. Expected one of: %s", options.Provider, strings.Join(ProviderNames(), ", "))
+    }
+
+    switch options.AddressingStyle {
+    case "", ADDRESSING_STYLE_AUTO, ADDRESSING_STYLE_PATH, ADDRESSING_STYLE_VIRTUAL:
+    default:
+        return fmt.Errorf("Unknown addressing style %q. Expected one of: path, virtual, auto", options.AddressingStyle)
+    }
+
+    switch options.SignatureVersion {
+    case "", SIGNATURE_VERSION_V2, SIGNATURE_VERSION_V4:
+    default:
+        return fmt.Errorf("Unknown signature version %q. Expected one of: v2, v4", options.SignatureVersion)
+    }
+
+    if options.MultipartThreshold > MAX_COPY_OBJECT_SIZE {
+        return fmt.Errorf("--multipart-threshold can't be more than %d bytes, the largest object S3 copies in one go.", MAX_COPY_OBJECT_SIZE)
+    }
+
+    if provider.RequiresEndpoint && options.Endpoint == "" {
+        return fmt.Errorf("The %s provider requires --endpoint-url.", options.Provider)
+    }
+
+    return nil
+}
+
+// Fills in anything left unset on the options from the provider preset.
+func applyProviderDefaults(options *ClientOptions) *ClientOptions {
+    resolved := *options
+    provider := providers[strings.ToLower(options.Provider)]
+
+    if resolved.AddressingStyle == "" {
+        resolved.AddressingStyle = provider.AddressingStyle
+    }
+
+    if resolved.SignatureVersion == "" {
+        resolved.SignatureVersion = provider.SignatureVersion
+    }
+
+    if resolved.Region == "" {
+        resolved.Region = provider.Region
+    }
+
+    if resolved.Endpoint == "" && provider.Endpoint != "" {
+        region := resolved.Region
+        if region == "" {
+            region = DEFAULT_REGION
+        }
+        resolved.Endpoint = fmt.Sprintf(provider.Endpoint, region)
+    }
+
+    return &resolved
+}
+
+// Whether buckets should be part of the path rather than the host name.
+func usePathStyle(options *ClientOptions) bool {
+    switch options.AddressingStyle {
+    case ADDRESSING_STYLE_PATH:
+        return true
+    case ADDRESSING_STYLE_VIRTUAL:
+        return false
+    }
+
+    // Custom endpoints rarely have wildcard DNS for bucket names, so auto only keeps
+    // virtual-hosted addressing for AWS itself.
+    return options.Endpoint != "" && !isAmazonEndpoint(options.Endpoint)
+}
+
+func isAmazonEndpoint(endpoint string) bool {
+    if !strings.Contains(endpoint, "://") {
+        endpoint = "https://" + endpoint
+    }
+
+    u, err := url.Parse(endpoint)
+
+    if err != nil {
+        return false
+    }
+
+    host := strings.ToLower(u.Hostname())
+
+    return strings.HasSuffix(host, ".amazonaws.com") || strings.HasSuffix(host, ".amazonaws.com.cn")
+}
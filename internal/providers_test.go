@@ -0,0 +1,68 @@
+package s3go
+
+import (
+    "testing"
+)
+
+func TestValidateClientOptions(t *testing.T) {
+    cases := []struct {
+        options ClientOptions
+        valid   bool
+    }{
+        {ClientOptions{}, true},
+        {ClientOptions{Provider: "minio", Endpoint: "http://localhost:9000"}, true},
+        {ClientOptions{Provider: "MinIO", Endpoint: "http://localhost:9000"}, true},
+        {ClientOptions{Provider: "minio"}, false},
+        {ClientOptions{Provider: "wasabi"}, true},
+        {ClientOptions{Provider: "backblaze"}, false},
+        {ClientOptions{AddressingStyle: "path"}, true},
+        {ClientOptions{AddressingStyle: "dns"}, false},
+        {ClientOptions{SignatureVersion: "v2"}, true},
+        {ClientOptions{SignatureVersion: "v3"}, false},
+        {ClientOptions{MultipartThreshold: MAX_COPY_OBJECT_SIZE}, true},
+        {ClientOptions{MultipartThreshold: MAX_COPY_OBJECT_SIZE + 1}, false},
+    }
+
+    for _, c := range cases {
+        err := ValidateClientOptions(&c.options)
+
+        if (err == nil) != c.valid {
+            t.Errorf("%+v: got %v, want valid=%v", c.options, err, c.valid)
+        }
+    }
+}
+
+func TestApplyProviderDefaults(t *testing.T) {
+    options := applyProviderDefaults(&ClientOptions{Provider: "ceph", Endpoint: "http://rgw:7480"})
+
+    if options.AddressingStyle != "path" || options.SignatureVersion != "v4" || options.Region != "us-east-1" {
+        t.Errorf("ceph defaults were not applied: %+v", options)
+    }
+
+    // Anything set explicitly wins over the preset.
+    options = applyProviderDefaults(&ClientOptions{Provider: "wasabi", Region: "eu-central-1", AddressingStyle: "path"})
+
+    if options.AddressingStyle != "path" || options.Region != "eu-central-1" || options.Endpoint != "https://s3.eu-central-1.wasabisys.com" {
+        t.Errorf("wasabi defaults overrode explicit options: %+v", options)
+    }
+}
+
+func TestUsePathStyle(t *testing.T) {
+    cases := []struct {
+        options ClientOptions
+        want    bool
+    }{
+        {ClientOptions{}, false},
+        {ClientOptions{AddressingStyle: "path"}, true},
+        {ClientOptions{AddressingStyle: "virtual", Endpoint: "http://localhost:9000"}, false},
+        {ClientOptions{AddressingStyle: "auto", Endpoint: "http://localhost:9000"}, true},
+        {ClientOptions{Endpoint: "https://s3.eu-west-1.amazonaws.com"}, false},
+        {ClientOptions{Endpoint: "s3.cn-north-1.amazonaws.com.cn"}, false},
+    }
+
+    for _, c := range cases {
+        if got := usePathStyle(&c.options); got != c.want {
+            t.Errorf("%+v: got %v, want %v", c.options, got, c.want)
+        }
+    }
+}
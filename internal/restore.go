@@ -0,0 +1,168 @@
+package s3go
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "regexp"
+    "strings"
+    "time"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/awserr"
+    "github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+    RESTORE_TIER_EXPEDITED = "Expedited"
+    RESTORE_TIER_STANDARD  = "Standard"
+    RESTORE_TIER_BULK      = "Bulk"
+
+    // Not one of the error codes the SDK declares
+    ERR_CODE_RESTORE_ALREADY_IN_PROGRESS = "RestoreAlreadyInProgress"
+)
+
+type RestoreObjectOptions struct {
+    // Number of days the restored copy is kept for
+    Days int64
+
+    // Retrieval tier: Expedited, Standard or Bulk. S3 uses Standard when it's empty.
+    Tier string
+
+    // Confirms that the requester knows that she or he will be charged for the request.
+    RequestPayer string
+}
+
+// Normalizes the case of a retrieval tier, returning an error if it isn't one S3 knows.
+func ParseRestoreTier(tier string) (string, error) {
+    for _, known := range []string{RESTORE_TIER_EXPEDITED, RESTORE_TIER_STANDARD, RESTORE_TIER_BULK} {
+        if strings.EqualFold(tier, known) {
+            return known, nil
+        }
+    }
+    return "", fmt.Errorf("Unknown restore tier %q. Expected one of: Expedited, Standard, Bulk", tier)
+}
+
+// Executes RestoreObject API operation on a single S3 key. A restore that is already in progress
+// isn't an error, so that restores can safely be requested again.
+func(c *Client) RestoreObject(ctx context.Context, object ObjectInfo, options *RestoreObjectOptions) error {
+    request := &s3.RestoreRequest{
+        Days: aws.Int64(options.Days),
+    }
+
+    if options.Tier != "" {
+        request.GlacierJobParameters = &s3.GlacierJobParameters{Tier: aws.String(options.Tier)}
+    }
+
+    _, err := c.svc.RestoreObjectWithContext(ctx, &s3.RestoreObjectInput{
+        Bucket:         object.Bucket,
+        Key:            object.Key,
+        VersionId:      object.VersionId,
+        RequestPayer:   aws.String(options.RequestPayer),
+        RestoreRequest: request,
+    })
+
+    if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ERR_CODE_RESTORE_ALREADY_IN_PROGRESS {
+        return nil
+    }
+
+    return err
+}
+
+// Changes the storage class of an object in place by copying it over itself, e.g. to move a
+// restored object out of Glacier for good. Everything else about the object is kept, see copyInPlace.
+func(c *Client) ChangeStorageClass(ctx context.Context, object ObjectInfo, storageClass, requestPayer string) error {
+    return c.copyInPlace(ctx, object, &MoveObjectOptions{
+        StorageClass: storageClass,
+        RequestPayer: requestPayer,
+    })
+}
+
+// Whether the object is archived, i.e. has to be restored before it can be read.
+func (o *ObjectInfo) IsArchived() bool {
+    switch aws.StringValue(o.StorageClass) {
+    case s3.StorageClassGlacier, s3.StorageClassDeepArchive:
+        return true
+    }
+    return false
+}
+
+type RestoreState int
+
+const (
+    RESTORE_NOT_STARTED RestoreState = iota
+    RESTORE_IN_PROGRESS
+    RESTORE_AVAILABLE
+)
+
+// Where an archived object is in being restored, from the x-amz-restore header.
+type RestoreStatus struct {
+    State RestoreState
+
+    // When the restored copy expires. Only set once it is available.
+    ExpiryDate *time.Time
+}
+
+// e.g. "in-progress" or "available until 2020-10-08 00:00:00"
+func (s RestoreStatus) String() string {
+    switch s.State {
+    case RESTORE_IN_PROGRESS:
+        return "in-progress"
+    case RESTORE_AVAILABLE:
+        if s.ExpiryDate == nil {
+            return "available"
+        }
+        return "available until " + s.ExpiryDate.UTC().Format("2006-01-02 15:04:05")
+    }
+    return "not-started"
+}
+
+var restoreHeaderRe = regexp.MustCompile(`ongoing-request="(true|false)"(?:,\s*expiry-date="([^"]+)")?`)
+
+// Parses an x-amz-restore header, e.g. `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`.
+// An empty header means no restore has been requested.
+func ParseRestoreHeader(header string) (RestoreStatus, error) {
+    if header == "" {
+        return RestoreStatus{State: RESTORE_NOT_STARTED}, nil
+    }
+
+    match := restoreHeaderRe.FindStringSubmatch(header)
+
+    if match == nil {
+        return RestoreStatus{}, fmt.Errorf("Unexpected x-amz-restore header: %s", header)
+    }
+
+    if match[1] == "true" {
+        return RestoreStatus{State: RESTORE_IN_PROGRESS}, nil
+    }
+
+    status := RestoreStatus{State: RESTORE_AVAILABLE}
+
+    if match[2] != "" {
+        expiryDate, err := http.ParseTime(match[2])
+
+        if err != nil {
+            return RestoreStatus{}, fmt.Errorf("Unexpected expiry date in x-amz-restore header: %s", header)
+        }
+
+        status.ExpiryDate = &expiryDate
+    }
+
+    return status, nil
+}
+
+// Looks up how far along the restore of an archived object is.
+func(c *Client) GetRestoreStatus(ctx context.Context, object ObjectInfo, requestPayer string) (RestoreStatus, error) {
+    output, err := c.svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+        Bucket:       object.Bucket,
+        Key:          object.Key,
+        VersionId:    object.VersionId,
+        RequestPayer: aws.String(requestPayer),
+    })
+
+    if err != nil {
+        return RestoreStatus{}, err
+    }
+
+    return ParseRestoreHeader(aws.StringValue(output.Restore))
+}
@@ -0,0 +1,114 @@
+package s3go
+
+import (
+    "encoding/json"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+const (
+    // The restore has been requested, but the object hasn't been handled since
+    RESTORE_STEP_REQUESTED = "requested"
+
+    // The object has been restored and any follow-up copy or storage class change is done
+    RESTORE_STEP_DONE = "done"
+)
+
+// Remembers how far each object of a `restore --wait` run has got, so that a run that was
+// interrupted, e.g. by a laptop going to sleep, can pick up where it left off. Without a path
+// it only lives in memory.
+type RestoreStateFile struct {
+    path string
+
+    mu sync.Mutex
+
+    // Step of each object, keyed by its URL
+    Objects map[string]string `json:"objects"`
+}
+
+// Loads the state file at path. A file that doesn't exist yet is the same as an empty one.
+func LoadRestoreStateFile(path string) (*RestoreStateFile, error) {
+    state := &RestoreStateFile{path: path, Objects: make(map[string]string)}
+
+    if path == "" {
+        return state, nil
+    }
+
+    body, err := ioutil.ReadFile(path)
+
+    if os.IsNotExist(err) {
+        return state, nil
+    }
+
+    if err != nil {
+        return nil, err
+    }
+
+    if err = json.Unmarshal(body, state); err != nil {
+        return nil, err
+    }
+
+    if state.Objects == nil {
+        state.Objects = make(map[string]string)
+    }
+
+    return state, nil
+}
+
+// The step the object has reached, or "" if it hasn't been seen before.
+func(f *RestoreStateFile) Step(object ObjectInfo) string {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    return f.Objects[restoreStateKey(object)]
+}
+
+// Records the step the object has reached and saves the file.
+func(f *RestoreStateFile) SetStep(object ObjectInfo, step string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    f.Objects[restoreStateKey(object)] = step
+
+    if f.path == "" {
+        return nil
+    }
+
+    body, err := json.MarshalIndent(f, "", "  ")
+
+    if err != nil {
+        return err
+    }
+
+    // Written to a temporary file first, so that a crash mid-write can't leave a truncated file behind.
+    tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path) + ".tmp")
+
+    if err != nil {
+        return err
+    }
+
+    if _, err = tmp.Write(body); err == nil {
+        err = tmp.Close()
+    } else {
+        tmp.Close()
+    }
+
+    if err == nil {
+        err = os.Rename(tmp.Name(), f.path)
+    }
+
+    if err != nil {
+        os.Remove(tmp.Name())
+    }
+
+    return err
+}
+
+func restoreStateKey(object ObjectInfo) string {
+    if object.VersionId != nil {
+        return object.Url() + "?versionId=" + *object.VersionId
+    }
+    return object.Url()
+}
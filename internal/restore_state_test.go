@@ -0,0 +1,58 @@
+package s3go
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/aws/aws-sdk-go/aws"
+)
+
+func TestRestoreStateFile(t *testing.T) {
+    dir, err := ioutil.TempDir("", "s3go-restore-state")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    path := filepath.Join(dir, "state.json")
+    object := ObjectInfo{Bucket: aws.String("bucket"), Key: aws.String("a.bin")}
+    version := ObjectInfo{Bucket: aws.String("bucket"), Key: aws.String("a.bin"), VersionId: aws.String("v1")}
+
+    state, err := LoadRestoreStateFile(path)
+    if err != nil {
+        t.Fatalf("a missing state file should load as empty: %v", err)
+    }
+
+    if step := state.Step(object); step != "" {
+        t.Errorf("got step %q for an unseen object", step)
+    }
+
+    if err := state.SetStep(object, RESTORE_STEP_REQUESTED); err != nil {
+        t.Fatal(err)
+    }
+
+    if err := state.SetStep(version, RESTORE_STEP_DONE); err != nil {
+        t.Fatal(err)
+    }
+
+    reloaded, err := LoadRestoreStateFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if step := reloaded.Step(object); step != RESTORE_STEP_REQUESTED {
+        t.Errorf("got step %q, want %q", step, RESTORE_STEP_REQUESTED)
+    }
+
+    // Versions of the same key are tracked separately.
+    if step := reloaded.Step(version); step != RESTORE_STEP_DONE {
+        t.Errorf("got step %q for the version, want %q", step, RESTORE_STEP_DONE)
+    }
+
+    // Nothing is left behind from writing the file.
+    if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+        t.Errorf("got %d files in the directory, want just the state file", len(files))
+    }
+}
@@ -0,0 +1,46 @@
+package s3go
+
+import (
+    "testing"
+)
+
+func TestParseRestoreHeader(t *testing.T) {
+    var tests = []struct {
+        header string
+        want   string
+    }{
+        {``, "not-started"},
+        {`ongoing-request="true"`, "in-progress"},
+        {`ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`, "available until 2012-12-21 00:00:00"},
+        {`ongoing-request="false"`, "available"},
+    }
+
+    for _, tt := range tests {
+        status, err := ParseRestoreHeader(tt.header)
+
+        if err != nil {
+            t.Errorf("%q: unexpected error %v", tt.header, err)
+            continue
+        }
+
+        if status.String() != tt.want {
+            t.Errorf("%q: got %s, want %s", tt.header, status, tt.want)
+        }
+    }
+
+    if _, err := ParseRestoreHeader(`something else`); err == nil {
+        t.Errorf("expected an error for an unrecognized header")
+    }
+}
+
+func TestParseRestoreTier(t *testing.T) {
+    for input, want := range map[string]string{"expedited": "Expedited", "Standard": "Standard", "BULK": "Bulk"} {
+        if tier, err := ParseRestoreTier(input); err != nil || tier != want {
+            t.Errorf("%s: got %s (%v), want %s", input, tier, err, want)
+        }
+    }
+
+    if _, err := ParseRestoreTier("fast"); err == nil {
+        t.Errorf("expected an error for an unknown tier")
+    }
+}
@@ -0,0 +1,101 @@
+package s3go
+
+import (
+    "fmt"
+)
+
+type ResultStatus int
+
+const (
+    RESULT_SUCCEEDED ResultStatus = iota
+    RESULT_FAILED
+    RESULT_SKIPPED
+    RESULT_CANCELLED
+)
+
+// The outcome of a command for a single object, reported by workers instead of the object itself.
+type ObjectResult struct {
+    // The object the command was run against
+    Object ObjectInfo
+
+    Status ResultStatus
+
+    // What was (or would have been) done, e.g. "upload: ./a.txt to s3://bucket/a.txt"
+    Message string
+
+    // Why the command failed. Only set when Status is RESULT_FAILED.
+    Err error
+}
+
+func NewSucceededResult(object ObjectInfo, message string) ObjectResult {
+    return ObjectResult{Object: object, Status: RESULT_SUCCEEDED, Message: message}
+}
+
+func NewFailedResult(object ObjectInfo, message string, err error) ObjectResult {
+    return ObjectResult{Object: object, Status: RESULT_FAILED, Message: message, Err: err}
+}
+
+func NewSkippedResult(object ObjectInfo, message string) ObjectResult {
+    return ObjectResult{Object: object, Status: RESULT_SKIPPED, Message: message}
+}
+
+// For objects that were in flight when the command was interrupted.
+func NewCancelledResult(object ObjectInfo, message string) ObjectResult {
+    return ObjectResult{Object: object, Status: RESULT_CANCELLED, Message: message}
+}
+
+// Tallies the results of a command run.
+type ResultSummary struct {
+    Succeeded int
+
+    Failed int
+
+    Skipped int
+
+    Cancelled int
+}
+
+func(s *ResultSummary) Add(result ObjectResult) {
+    switch result.Status {
+    case RESULT_SUCCEEDED:
+        s.Succeeded++
+    case RESULT_FAILED:
+        s.Failed++
+    case RESULT_SKIPPED:
+        s.Skipped++
+    case RESULT_CANCELLED:
+        s.Cancelled++
+    }
+}
+
+// Adds the counts of another summary, e.g. the deletes that follow the transfers of a sync.
+func(s *ResultSummary) Merge(other *ResultSummary) {
+    s.Succeeded += other.Succeeded
+    s.Failed += other.Failed
+    s.Skipped += other.Skipped
+    s.Cancelled += other.Cancelled
+}
+
+func(s *ResultSummary) Total() int {
+    return s.Succeeded + s.Failed + s.Skipped + s.Cancelled
+}
+
+// Mirrors the aws cli: 0 when nothing failed, 1 when some objects failed and 2 when all of them did.
+func(s *ResultSummary) ExitCode() int {
+    if s.Failed == 0 {
+        return 0
+    }
+
+    if s.Succeeded == 0 && s.Skipped == 0 {
+        return 2
+    }
+
+    return 1
+}
+
+func(s *ResultSummary) String() string {
+    if s.Cancelled > 0 {
+        return fmt.Sprintf("Completed: %d succeeded, %d failed, %d skipped, %d cancelled.", s.Succeeded, s.Failed, s.Skipped, s.Cancelled)
+    }
+    return fmt.Sprintf("Completed: %d succeeded, %d failed, %d skipped.", s.Succeeded, s.Failed, s.Skipped)
+}
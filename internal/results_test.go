@@ -0,0 +1,42 @@
+package s3go
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestResultSummaryExitCode(t *testing.T) {
+    object := ObjectInfo{}
+
+    var tests = []struct {
+        results []ObjectResult
+        want    int
+    }{
+        {[]ObjectResult{}, 0},
+        {[]ObjectResult{NewSucceededResult(object, ""), NewSkippedResult(object, "")}, 0},
+        {[]ObjectResult{NewSucceededResult(object, ""), NewFailedResult(object, "", errors.New("boom"))}, 1},
+        {[]ObjectResult{NewSkippedResult(object, ""), NewFailedResult(object, "", errors.New("boom"))}, 1},
+        {[]ObjectResult{NewFailedResult(object, "", errors.New("boom")), NewFailedResult(object, "", errors.New("boom"))}, 2},
+    }
+
+    for _, tt := range tests {
+        summary := &ResultSummary{}
+
+        for _, result := range tt.results {
+            summary.Add(result)
+        }
+
+        if code := summary.ExitCode(); code != tt.want {
+            t.Errorf("%s: got exit code %d, want %d", summary, code, tt.want)
+        }
+    }
+}
+
+func TestResultSummaryMerge(t *testing.T) {
+    summary := &ResultSummary{Succeeded: 2, Skipped: 1}
+    summary.Merge(&ResultSummary{Succeeded: 1, Failed: 3})
+
+    if summary.String() != "Completed: 3 succeeded, 3 failed, 1 skipped." {
+        t.Errorf("got %s", summary)
+    }
+}
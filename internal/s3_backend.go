@@ -0,0 +1,680 @@
+package s3go
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net/url"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/awserr"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/s3"
+    "github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Backend for objects stored in S3 (or anything that speaks the S3 API).
+type S3Backend struct {
+    // Authenticated S3 client to make API requests
+    svc *s3.S3
+
+    // Session used to build clients for buckets in other regions
+    sess *session.Session
+
+    // Original options used to configure the backend
+    options *ClientOptions
+
+    uploader *s3manager.Uploader
+
+    downloader *s3manager.Downloader
+
+    // Clients for buckets outside of the configured region, keyed by region
+    regionSvcs map[string]*s3.S3
+
+    mu sync.Mutex
+}
+
+// Bucket regions are cached for the life of the process, keyed by endpoint and bucket name.
+var bucketRegions sync.Map
+
+func NewS3Backend(sess *session.Session, options *ClientOptions) *S3Backend {
+    svc := newS3Service(sess, options)
+
+    uploader := s3manager.NewUploaderWithClient(svc, func(u *s3manager.Uploader) {
+        if options.PartSize > 0 {
+            u.PartSize = options.PartSize
+        }
+        if options.MultipartConcurrency > 0 {
+            u.Concurrency = options.MultipartConcurrency
+        }
+    })
+
+    downloader := s3manager.NewDownloaderWithClient(svc)
+
+    return &S3Backend{
+        svc:        svc,
+        sess:       sess,
+        options:    options,
+        uploader:   uploader,
+        downloader: downloader,
+        regionSvcs: make(map[string]*s3.S3),
+    }
+}
+
+// Resolves the region of a bucket with an authenticated HeadBucket request against the
+// configured endpoint. S3 reports the region even when the request went to the wrong one.
+func(b *S3Backend) BucketRegion(bucket string) (string, error) {
+    cacheKey := b.svc.Endpoint + "/" + bucket
+
+    if region, ok := bucketRegions.Load(cacheKey); ok {
+        return region.(string), nil
+    }
+
+    req, _ := b.svc.HeadBucketRequest(&s3.HeadBucketInput{
+        Bucket: aws.String(bucket),
+    })
+
+    err := req.Send()
+    region := ""
+
+    if req.HTTPResponse != nil {
+        region = req.HTTPResponse.Header.Get("X-Amz-Bucket-Region")
+    }
+
+    if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == 404 {
+        return "", errors.New("(NoSuchBucket) Provided bucket does not exist")
+    }
+
+    if err != nil && region == "" {
+        return "", err
+    }
+
+    // S3 compatible systems don't always report a region, in which case ours is as good as any.
+    if region == "" {
+        region = aws.StringValue(b.svc.Config.Region)
+    }
+
+    bucketRegions.Store(cacheKey, region)
+
+    return region, nil
+}
+
+// A client scoped to the region of the bucket. The configured endpoint is always kept.
+func(b *S3Backend) serviceForBucket(bucket string) (*s3.S3, error) {
+    region, err := b.BucketRegion(bucket)
+
+    if err != nil {
+        return nil, err
+    }
+
+    if region == aws.StringValue(b.svc.Config.Region) {
+        return b.svc, nil
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    if svc, ok := b.regionSvcs[region]; ok {
+        return svc, nil
+    }
+
+    options := *b.options
+    options.Region = region
+
+    svc := newS3Service(b.sess, &options)
+    b.regionSvcs[region] = svc
+
+    return svc, nil
+}
+
+// List objects in an S3 bucket and prefix using the list-objects-v2 API method.
+func(b *S3Backend) List(ctx context.Context, options *ListObjectsV2Input) (<-chan ObjectInfo, error) {
+    delimiter := ""
+    if !options.Recursive {
+        delimiter = "/"
+    }
+
+    // The bucket being listed may live in a different region than the backend.
+    svc, err := b.serviceForBucket(options.Bucket)
+
+    if err != nil {
+        return nil, err
+    }
+
+    if !options.Recursive && !options.Directory {
+        emptyCh := make(chan ObjectInfo)
+        defer close(emptyCh)
+
+        if options.Prefix == "" {
+            return emptyCh, nil
+        }
+
+        algorithm, customerKey := customerKeyParams(options.CustomerKey)
+
+        output, err := svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+            Bucket:               aws.String(options.Bucket),
+            Key:                  aws.String(options.Prefix),
+            VersionId:            nonEmptyString(options.VersionId),
+            SSECustomerAlgorithm: algorithm,
+            SSECustomerKey:       customerKey,
+        })
+
+        if err != nil {
+            return nil, err
+        }
+
+        // Listing can't see old versions, so the object is built from what HeadObject returned.
+        if options.VersionId != "" {
+            outputCh := make(chan ObjectInfo, 1)
+            defer close(outputCh)
+
+            outputCh <- ObjectInfo{
+                Bucket:       aws.String(options.Bucket),
+                Key:          aws.String(options.Prefix),
+                ETag:         output.ETag,
+                Size:         output.ContentLength,
+                LastModified: output.LastModified,
+                StorageClass: output.StorageClass,
+                VersionId:    output.VersionId,
+            }
+
+            return outputCh, nil
+        }
+    }
+
+    input := &s3.ListObjectsV2Input{
+        Bucket:    aws.String(options.Bucket),
+        Prefix:    aws.String(options.Prefix),
+        Delimiter: aws.String(delimiter),
+    }
+
+    filterRoot := options.Prefix
+    if options.Recursive {
+        filterRoot = directoryPrefix(filterRoot)
+    }
+
+    filter, err := newFilterMatcher(options.Filters, filterRoot, false)
+    if err != nil {
+        return nil, err
+    }
+
+    outputCh := make(chan ObjectInfo)
+
+    go func() {
+        defer close(outputCh)
+
+        err := svc.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+            for _, prefix := range page.CommonPrefixes {
+                objectInfo := ObjectInfo{
+                    Bucket:       &options.Bucket,
+                    Key:          prefix.Prefix,
+                    IsPrefix:     true,
+                    Size:         new(int64),
+                    LastModified: &time.Time{},
+                }
+                if !sendObject(ctx, outputCh, objectInfo) {
+                    return false
+                }
+            }
+
+            for _, object := range page.Contents {
+                if !filter.includes(*object.Key) {
+                    continue
+                }
+                objectKey := *object.Key
+                objectInfo := ObjectInfo{
+                    Bucket:       &options.Bucket,
+                    Key:          object.Key,
+                    ETag:         object.ETag,
+                    IsPrefix:     objectKey[len(objectKey)-1:] == "/",
+                    Size:         object.Size,
+                    LastModified: object.LastModified,
+                    StorageClass: object.StorageClass,
+                }
+                if !sendObject(ctx, outputCh, objectInfo) {
+                    return false
+                }
+            }
+
+            return !lastPage
+        })
+
+        // An interrupted listing is reported by the command itself.
+        if err != nil && ctx.Err() == nil {
+            sendObject(ctx, outputCh, listingError(options.Bucket, options.Prefix, err))
+        }
+    }()
+
+    return outputCh, nil
+}
+
+// List every version of the objects under a prefix, newest first within each key, using the
+// list-object-versions API method. Delete markers are included.
+func(b *S3Backend) ListVersions(ctx context.Context, options *ListObjectsV2Input) (<-chan ObjectInfo, error) {
+    delimiter := ""
+    if !options.Recursive {
+        delimiter = "/"
+    }
+
+    svc, err := b.serviceForBucket(options.Bucket)
+
+    if err != nil {
+        return nil, err
+    }
+
+    filterRoot := options.Prefix
+    if options.Recursive {
+        filterRoot = directoryPrefix(filterRoot)
+    }
+
+    filter, err := newFilterMatcher(options.Filters, filterRoot, false)
+    if err != nil {
+        return nil, err
+    }
+
+    input := &s3.ListObjectVersionsInput{
+        Bucket:    aws.String(options.Bucket),
+        Prefix:    aws.String(options.Prefix),
+        Delimiter: aws.String(delimiter),
+    }
+
+    outputCh := make(chan ObjectInfo)
+
+    go func() {
+        defer close(outputCh)
+
+        err := svc.ListObjectVersionsPagesWithContext(ctx, input, func(page *s3.ListObjectVersionsOutput, lastPage bool) bool {
+            for _, prefix := range page.CommonPrefixes {
+                objectInfo := ObjectInfo{
+                    Bucket:       &options.Bucket,
+                    Key:          prefix.Prefix,
+                    IsPrefix:     true,
+                    Size:         new(int64),
+                    LastModified: &time.Time{},
+                }
+                if !sendObject(ctx, outputCh, objectInfo) {
+                    return false
+                }
+            }
+
+            for _, version := range sortedVersions(page) {
+                if !filter.includes(*version.Key) {
+                    continue
+                }
+                version.Bucket = &options.Bucket
+                if !sendObject(ctx, outputCh, version) {
+                    return false
+                }
+            }
+
+            return !lastPage
+        })
+
+        // An interrupted listing is reported by the command itself.
+        if err != nil && ctx.Err() == nil {
+            sendObject(ctx, outputCh, listingError(options.Bucket, options.Prefix, err))
+        }
+    }()
+
+    return outputCh, nil
+}
+
+// S3 returns versions and delete markers as separate lists. They are merged back into key order,
+// newest first, which is how they are interleaved in the response.
+func sortedVersions(page *s3.ListObjectVersionsOutput) []ObjectInfo {
+    versions := make([]ObjectInfo, 0, len(page.Versions) + len(page.DeleteMarkers))
+
+    for _, version := range page.Versions {
+        versions = append(versions, ObjectInfo{
+            Key:          version.Key,
+            ETag:         version.ETag,
+            Size:         version.Size,
+            LastModified: version.LastModified,
+            StorageClass: version.StorageClass,
+            VersionId:    version.VersionId,
+            IsLatest:     aws.BoolValue(version.IsLatest),
+        })
+    }
+
+    for _, marker := range page.DeleteMarkers {
+        versions = append(versions, ObjectInfo{
+            Key:            marker.Key,
+            Size:           new(int64),
+            LastModified:   marker.LastModified,
+            VersionId:      marker.VersionId,
+            IsLatest:       aws.BoolValue(marker.IsLatest),
+            IsDeleteMarker: true,
+        })
+    }
+
+    sort.SliceStable(versions, func(i, j int) bool {
+        if *versions[i].Key != *versions[j].Key {
+            return *versions[i].Key < *versions[j].Key
+        }
+        if versions[i].IsLatest != versions[j].IsLatest {
+            return versions[i].IsLatest
+        }
+        return versions[i].LastModified.After(*versions[j].LastModified)
+    })
+
+    return versions
+}
+
+func(b *S3Backend) Stat(ctx context.Context, bucket, key string, options *MoveObjectOptions) (*ObjectInfo, error) {
+    algorithm, customerKey := customerKeyParams(options.Encryption.CustomerKey)
+
+    output, err := b.svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+        Bucket:               aws.String(bucket),
+        Key:                  aws.String(key),
+        RequestPayer:         aws.String(options.RequestPayer),
+        SSECustomerAlgorithm: algorithm,
+        SSECustomerKey:       customerKey,
+    })
+
+    if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == 404 {
+        return nil, nil
+    }
+
+    if err != nil {
+        return nil, err
+    }
+
+    return &ObjectInfo{
+        Bucket:       aws.String(bucket),
+        Key:          aws.String(key),
+        ETag:         output.ETag,
+        Size:         output.ContentLength,
+        LastModified: output.LastModified,
+    }, nil
+}
+
+// https://github.com/awsdocs/aws-doc-sdk-examples/blob/master/go/example_code/s3/s3_download_object.go
+func(b *S3Backend) Get(ctx context.Context, object ObjectInfo, w io.WriterAt, options *MoveObjectOptions) error {
+    concurrency := b.downloader.Concurrency
+
+    // Streams can only be written in order, so parts have to be fetched one at a time.
+    if _, ok := w.(*sequentialWriterAt); ok {
+        concurrency = 1
+    }
+
+    algorithm, customerKey := customerKeyParams(options.Encryption.SourceCustomerKey)
+
+    _, err := b.downloader.DownloadWithContext(ctx, w, &s3.GetObjectInput{
+        Bucket:               aws.String(*object.Bucket),
+        Key:                  aws.String(*object.Key),
+        VersionId:            object.VersionId,
+        RequestPayer:         aws.String(options.RequestPayer),
+        SSECustomerAlgorithm: algorithm,
+        SSECustomerKey:       customerKey,
+    }, func(d *s3manager.Downloader) {
+        d.Concurrency = concurrency
+    })
+
+    return err
+}
+
+// Fetches the rest of an object with a single ranged GET. If-Match makes S3 refuse the request
+// when the object has been replaced since it was listed.
+func(b *S3Backend) GetRange(ctx context.Context, object ObjectInfo, w io.Writer, start int64, options *MoveObjectOptions) error {
+    algorithm, customerKey := customerKeyParams(options.Encryption.SourceCustomerKey)
+
+    output, err := b.svc.GetObjectWithContext(ctx, &s3.GetObjectInput{
+        Bucket:               aws.String(*object.Bucket),
+        Key:                  aws.String(*object.Key),
+        VersionId:            object.VersionId,
+        Range:                aws.String(fmt.Sprintf("bytes=%d-", start)),
+        IfMatch:              object.ETag,
+        RequestPayer:         aws.String(options.RequestPayer),
+        SSECustomerAlgorithm: algorithm,
+        SSECustomerKey:       customerKey,
+    })
+
+    if err != nil {
+        return err
+    }
+
+    defer output.Body.Close()
+
+    _, err = io.Copy(w, output.Body)
+
+    return err
+}
+
+// https://github.com/awsdocs/aws-doc-sdk-examples/blob/master/go/example_code/s3/s3_upload_object.go
+func(b *S3Backend) Put(ctx context.Context, bucket, key string, r io.Reader, options *MoveObjectOptions) error {
+    algorithm, customerKey := customerKeyParams(options.Encryption.CustomerKey)
+
+    _, err := b.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+        Bucket:                  aws.String(bucket),
+        Key:                     aws.String(key),
+        Body:                    r,
+        ACL:                     nonEmptyString(options.ACL),
+        CacheControl:            nonEmptyString(options.CacheControl),
+        ContentDisposition:      nonEmptyString(options.ContentDisposition),
+        ContentEncoding:         nonEmptyString(options.ContentEncoding),
+        ContentType:             nonEmptyString(options.ContentType),
+        ContentLanguage:         nonEmptyString(options.ContentLanguage),
+        Expires:                 options.Expires,
+        Metadata:                aws.StringMap(options.Metadata),
+        RequestPayer:            nonEmptyString(options.RequestPayer),
+        StorageClass:            nonEmptyString(options.StorageClass),
+        Tagging:                 nonEmptyString(options.Tagging),
+        WebsiteRedirectLocation: nonEmptyString(options.WebsiteRedirect),
+        ServerSideEncryption:    options.Encryption.serverSideEncryption(),
+        SSEKMSKeyId:             options.Encryption.kmsKeyId(),
+        SSEKMSEncryptionContext: options.Encryption.kmsEncryptionContext(),
+        SSECustomerAlgorithm:    algorithm,
+        SSECustomerKey:          customerKey,
+    }, func(u *s3manager.Uploader) {
+        // Streams can't be measured up front, so the part size is picked from the size the caller expects.
+        if options.ExpectedSize > 0 {
+            u.PartSize = b.partSize(options.ExpectedSize)
+        }
+    })
+
+    // The uploader aborts failed multipart uploads with the same context, which doesn't work once
+    // it has been cancelled. Without this the parts would be left behind (and billed) forever.
+    if multierr, ok := err.(s3manager.MultiUploadFailure); ok && ctx.Err() != nil {
+        b.abortUpload(bucket, key, multierr.UploadID(), options.RequestPayer)
+    }
+
+    return err
+}
+
+// Aborts a multipart upload, independently of any context so that it still runs after an interrupt.
+func(b *S3Backend) abortUpload(bucket, key, uploadId, requestPayer string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30 * time.Second)
+    defer cancel()
+
+    _, err := b.svc.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+        Bucket:       aws.String(bucket),
+        Key:          aws.String(key),
+        UploadId:     aws.String(uploadId),
+        RequestPayer: aws.String(requestPayer),
+    })
+
+    return err
+}
+
+// https://github.com/awsdocs/aws-doc-sdk-examples/blob/master/go/example_code/s3/s3_copy_object.go
+func(b *S3Backend) Copy(ctx context.Context, object ObjectInfo, bucket, key string, options *MoveObjectOptions) error {
+    // S3 won't copy anything over 5 GiB in a single request.
+    if object.Size != nil && *object.Size > b.multipartThreshold() {
+        if err := b.copyMultipart(ctx, object, bucket, key, options); err != nil {
+            return err
+        }
+
+        return b.waitUntilCopied(ctx, bucket, key, options)
+    }
+
+    algorithm, customerKey := customerKeyParams(options.Encryption.CustomerKey)
+    sourceAlgorithm, sourceCustomerKey := customerKeyParams(options.Encryption.SourceCustomerKey)
+
+    input := &s3.CopyObjectInput{
+        CopySource:                     aws.String(copySource(object)),
+        Bucket:                         aws.String(bucket),
+        Key:                            aws.String(key),
+        ACL:                            nonEmptyString(options.ACL),
+        RequestPayer:                   nonEmptyString(options.RequestPayer),
+        StorageClass:                   nonEmptyString(options.StorageClass),
+        WebsiteRedirectLocation:        nonEmptyString(options.WebsiteRedirect),
+        ServerSideEncryption:           options.Encryption.serverSideEncryption(),
+        SSEKMSKeyId:                    options.Encryption.kmsKeyId(),
+        SSEKMSEncryptionContext:        options.Encryption.kmsEncryptionContext(),
+        SSECustomerAlgorithm:           algorithm,
+        SSECustomerKey:                 customerKey,
+        CopySourceSSECustomerAlgorithm: sourceAlgorithm,
+        CopySourceSSECustomerKey:       sourceCustomerKey,
+    }
+
+    applyReplacedMetadata(input, options)
+
+    if options.Tagging != "" {
+        input.TaggingDirective = aws.String(s3.TaggingDirectiveReplace)
+        input.Tagging = aws.String(options.Tagging)
+    }
+
+    if _, err := b.svc.CopyObjectWithContext(ctx, input); err != nil {
+        return err
+    }
+
+    return b.waitUntilCopied(ctx, bucket, key, options)
+}
+
+// Waits for a copied object to show up. Objects encrypted with SSE-C can only be looked up with their key.
+func(b *S3Backend) waitUntilCopied(ctx context.Context, bucket, key string, options *MoveObjectOptions) error {
+    algorithm, customerKey := customerKeyParams(options.Encryption.CustomerKey)
+
+    return b.svc.WaitUntilObjectExistsWithContext(ctx, &s3.HeadObjectInput{
+        Bucket:               aws.String(bucket),
+        Key:                  aws.String(key),
+        RequestPayer:         aws.String(options.RequestPayer),
+        SSECustomerAlgorithm: algorithm,
+        SSECustomerKey:       customerKey,
+    })
+}
+
+// Executes DeleteObject API operation on a single S3 key.
+func(b *S3Backend) Delete(ctx context.Context, object ObjectInfo, requestPayer string) error {
+    _, err := b.svc.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+        Bucket:       aws.String(*object.Bucket),
+        Key:          aws.String(*object.Key),
+        VersionId:    object.VersionId,
+        RequestPayer: aws.String(requestPayer),
+    })
+
+    return err
+}
+
+// Deletes up to DELETE_BATCH_SIZE objects per DeleteObjects request. Keys that S3 couldn't delete
+// get their own error from the response; if a whole request fails, all of its keys share that error.
+func(b *S3Backend) DeleteBatch(ctx context.Context, objects []ObjectInfo, requestPayer string) []error {
+    errs := make([]error, len(objects))
+
+    // A batch can only target one bucket, so keys are grouped by bucket first.
+    indexes := make(map[string][]int)
+    buckets := []string{}
+
+    for i, object := range objects {
+        if _, ok := indexes[*object.Bucket]; !ok {
+            buckets = append(buckets, *object.Bucket)
+        }
+        indexes[*object.Bucket] = append(indexes[*object.Bucket], i)
+    }
+
+    for _, bucket := range buckets {
+        bucketIndexes := indexes[bucket]
+
+        for start := 0; start < len(bucketIndexes); start += DELETE_BATCH_SIZE {
+            batch := bucketIndexes[start:IntMin(start + DELETE_BATCH_SIZE, len(bucketIndexes))]
+            b.deleteBatch(ctx, bucket, objects, batch, errs, requestPayer)
+        }
+    }
+
+    return errs
+}
+
+func(b *S3Backend) deleteBatch(ctx context.Context, bucket string, objects []ObjectInfo, batch []int, errs []error, requestPayer string) {
+    identifiers := make([]*s3.ObjectIdentifier, len(batch))
+    // The same key can appear once per version, so each key maps to every index it was sent with.
+    keyIndexes := make(map[string][]int, len(batch))
+
+    for i, index := range batch {
+        identifiers[i] = &s3.ObjectIdentifier{Key: objects[index].Key, VersionId: objects[index].VersionId}
+        keyIndexes[*objects[index].Key] = append(keyIndexes[*objects[index].Key], index)
+    }
+
+    output, err := b.svc.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+        Bucket:       aws.String(bucket),
+        RequestPayer: aws.String(requestPayer),
+        Delete: &s3.Delete{
+            Objects: identifiers,
+            Quiet:   aws.Bool(true),
+        },
+    })
+
+    if err != nil {
+        for _, index := range batch {
+            errs[index] = err
+        }
+        return
+    }
+
+    for _, deleteError := range output.Errors {
+        for _, index := range keyIndexes[aws.StringValue(deleteError.Key)] {
+            // Not every S3 compatible system echoes the version id back, in which case the error
+            // is applied to every version of the key.
+            if deleteError.VersionId != nil && aws.StringValue(objects[index].VersionId) != *deleteError.VersionId {
+                continue
+            }
+            errs[index] = awserr.New(aws.StringValue(deleteError.Code), aws.StringValue(deleteError.Message), nil)
+        }
+    }
+}
+
+// The x-amz-copy-source of an object, pinned to its version if it has one. S3 URL decodes it, so
+// the bucket and key are escaped apart from the slashes between them. Otherwise a key with "?" in
+// it would be cut short, and "%" or "+" would be decoded into something else.
+func copySource(object ObjectInfo) string {
+    segments := strings.Split(object.Path(), "/")
+
+    for i, segment := range segments {
+        segments[i] = strings.Replace(url.PathEscape(segment), "+", "%2B", -1)
+    }
+
+    source := strings.Join(segments, "/")
+
+    if object.VersionId == nil || *object.VersionId == "" {
+        return source
+    }
+    return source + "?versionId=" + url.QueryEscape(*object.VersionId)
+}
+
+// Leaves optional string parameters out of requests when they're empty.
+func nonEmptyString(s string) *string {
+    if s == "" {
+        return nil
+    }
+    return aws.String(s)
+}
+
+// The part size the uploader will use for an object of the given size.
+func(b *S3Backend) partSize(size int64) int64 {
+    partSize := b.uploader.PartSize
+
+    if partSize == 0 {
+        partSize = s3manager.DefaultUploadPartSize
+    }
+
+    // The uploader grows the part size so it never needs more than MaxUploadParts.
+    if size/partSize >= int64(s3manager.MaxUploadParts) {
+        partSize = (size / int64(s3manager.MaxUploadParts)) + 1
+    }
+
+    return partSize
+}
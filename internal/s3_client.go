@@ -1,15 +1,19 @@
 package s3go
 
 import (
+    "context"
+    "crypto/tls"
     "time"
     "fmt"
-    "errors"
+    "io"
+    "net/http"
     "os"
+    "path/filepath"
 
     "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/awserr"
     "github.com/aws/aws-sdk-go/aws/session"
     "github.com/aws/aws-sdk-go/service/s3"
-    "github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
 type Client struct {
@@ -22,9 +26,14 @@ type Client struct {
     // Original options used to configure the client
     options *ClientOptions
 
-    uploader *s3manager.Uploader
+    // Backend for S3 objects, also registered under the "s3" scheme
+    s3 *S3Backend
 
-    downloader *s3manager.Downloader
+    // Backend for S3 source objects when ClientOptions.Source is set, nil otherwise
+    source *S3Backend
+
+    // Backends that objects can be transferred between, keyed by URL scheme
+    backends map[string]Backend
 }
 
 type ClientOptions struct {
@@ -34,57 +43,157 @@ type ClientOptions struct {
     // Turn on debug logging
     Debug bool
 
-    // This option overrides the default behavior of verifying SSL certificates
+    // Send requests over plain HTTP instead of HTTPS
     DisableSSL bool
 
+    // Skip verifying SSL certificates, e.g. for an endpoint with a self-signed certificate
+    NoVerifySSL bool
+
     // Use a specific profile from your credential file
     Profile string
 
     // Specifies the AWS Region where the API actions will be scoped to
     Region string
+
+    // The size of each part of a multipart upload. Defaults to s3manager.DefaultUploadPartSize.
+    PartSize int64
+
+    // Objects copied within S3 that are larger than this are copied in parts. Defaults to MAX_COPY_OBJECT_SIZE.
+    MultipartThreshold int64
+
+    // Number of parts of a single object uploaded or copied in parallel. Defaults to s3manager.DefaultUploadConcurrency.
+    MultipartConcurrency int
+
+    // How buckets are addressed: path, virtual or auto (the default)
+    AddressingStyle string
+
+    // Request signing algorithm: v4 (the default) or v2
+    SignatureVersion string
+
+    // Named preset for an S3 compatible system, e.g. minio or ceph. See ProviderNames.
+    Provider string
+
+    // Separate profile, endpoint and region for the source of a transfer, e.g. a bucket in
+    // another account or on another S3 compatible system. Nil when both sides share these options.
+    Source *ClientOptions
 }
 
 func NewClient(options *ClientOptions) *Client {
+    sess, options := newSession(options)
+    s3Backend := NewS3Backend(sess, options)
+
+    backends := map[string]Backend{
+        "":   NewLocalBackend(),
+        "s3": s3Backend,
+    }
+
+    client := &Client{
+        svc:      s3Backend.svc,
+        sess:     sess,
+        options:  options,
+        s3:       s3Backend,
+        backends: backends,
+    }
+
+    if options.Source != nil {
+        sourceSess, sourceOptions := newSession(options.Source)
+        client.source = NewS3Backend(sourceSess, sourceOptions)
+    }
+
+    return client
+}
+
+// Creates a session for the options' profile and fills in the provider's defaults.
+func newSession(options *ClientOptions) (*session.Session, *ClientOptions) {
     sess, _ := session.NewSessionWithOptions(session.Options{
         Profile: options.Profile,
     })
 
-    svc := s3.New(sess, NewConfig(options))
+    options = applyProviderDefaults(options)
 
-    uploader := s3manager.NewUploaderWithClient(svc)
-
-    downloader := s3manager.NewDownloaderWithClient(svc)
+    if options.Region == "" && aws.StringValue(sess.Config.Region) == "" {
+        options.Region = DEFAULT_REGION
+    }
 
-    return &Client{svc, sess, options, uploader, downloader}
+    return sess, options
 }
 
 func NewConfig(options *ClientOptions) *aws.Config {
     cfg := &aws.Config{
-        Endpoint:   &options.Endpoint,
-        DisableSSL: &options.DisableSSL,
+        DisableSSL:       aws.Bool(options.DisableSSL),
+        S3ForcePathStyle: aws.Bool(usePathStyle(options)),
+    }
+
+    // Without an explicit endpoint, the SDK resolves the right one for the region.
+    if options.Endpoint != "" {
+        cfg = cfg.WithEndpoint(options.Endpoint)
     }
 
     if options.Region != "" {
         cfg = cfg.WithRegion(options.Region)
     }
 
+    if options.NoVerifySSL {
+        cfg = cfg.WithHTTPClient(insecureHTTPClient())
+    }
+
     return cfg
 }
 
-// Generates a pre-signed URL for an Amazon S3 object
-func (c *Client) Presign(bucketName string, key string, expireInSeconds int) (string, error) {
-    req, _ := c.svc.GetObjectRequest(&s3.GetObjectInput{
-        Bucket: aws.String(bucketName),
-        Key:    aws.String(key),
-    })
+// An HTTP client that accepts whatever certificate the server presents. Requests still use HTTPS.
+func insecureHTTPClient() *http.Client {
+    transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+
+    if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+        transport = defaultTransport.Clone()
+    }
+
+    if transport.TLSClientConfig == nil {
+        transport.TLSClientConfig = &tls.Config{}
+    }
 
-    presignedUrl, err := req.Presign(time.Duration(expireInSeconds) * time.Second)
+    transport.TLSClientConfig.InsecureSkipVerify = true
+
+    return &http.Client{Transport: transport}
+}
+
+// Builds an S3 service client, swapping in the v2 signer when the options ask for it.
+func newS3Service(sess *session.Session, options *ClientOptions) *s3.S3 {
+    svc := s3.New(sess, NewConfig(options))
+
+    if options.SignatureVersion == SIGNATURE_VERSION_V2 {
+        useSignatureV2(&svc.Handlers)
+    }
+
+    return svc
+}
+
+// Generates a pre-signed URL for an Amazon S3 object, or a specific version of it if versionId is set.
+// Objects encrypted with SSE-C need their customer key, which can't go in the URL. The headers that
+// carry it are returned instead, and whoever uses the URL has to send them along.
+func (c *Client) Presign(bucketName, key, versionId string, expireInSeconds int, customerKey []byte) (string, http.Header, error) {
+    algorithm, sseCustomerKey := customerKeyParams(customerKey)
+
+    input := &s3.GetObjectInput{
+        Bucket:               aws.String(bucketName),
+        Key:                  aws.String(key),
+        SSECustomerAlgorithm: algorithm,
+        SSECustomerKey:       sseCustomerKey,
+    }
+
+    if versionId != "" {
+        input.VersionId = aws.String(versionId)
+    }
+
+    req, _ := c.svc.GetObjectRequest(input)
+
+    presignedUrl, header, err := req.PresignRequest(time.Duration(expireInSeconds) * time.Second)
 
     if err != nil {
-        return "", err
+        return "", nil, err
     }
 
-    return presignedUrl, nil
+    return presignedUrl, header, nil
 }
 
 // Create an S3 bucket if one does not exist with that name.
@@ -104,20 +213,9 @@ func(c *Client) MakeBucket(bucketName, region string) error {
     return err
 }
 
-// Removes an S3 bucket if it  exists within the context of the current session.
-func(c *Client) RemoveBucket(bucketName string, forceFlag bool) error {
-    if (forceFlag) {
-        fmt.Println("Emptying the bucket. This might take a few minutes.")
-        return c.EmptyBucket(bucketName)
-    }
-
-    // We override the session since it's best to delete buckets from the main S3 endpoint.
-    svc := s3.New(c.sess, NewConfig(&ClientOptions{
-        Endpoint: fmt.Sprintf(DEFAULT_ENDPOINT_URL),
-        Region:   c.options.Region,
-    }))
-
-    _, err := svc.DeleteBucket(&s3.DeleteBucketInput{
+// Removes an empty S3 bucket and waits until it no longer exists.
+func(c *Client) RemoveBucket(ctx context.Context, bucketName string) error {
+    _, err := c.svc.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{
         Bucket: aws.String(bucketName),
     })
 
@@ -125,76 +223,175 @@ func(c *Client) RemoveBucket(bucketName string, forceFlag bool) error {
         return err
     }
 
-    err = svc.WaitUntilBucketNotExists(&s3.HeadBucketInput{
+    err = c.svc.WaitUntilBucketNotExistsWithContext(ctx, &s3.HeadBucketInput{
         Bucket: aws.String(bucketName),
     })
 
     return err
 }
-// Empty an entire S3 bucket using batch DeleteObjects API operations.
-func(c *Client) EmptyBucket(bucketName string) error {
-    iter := s3manager.NewDeleteListIterator(c.svc, &s3.ListObjectsInput{
+
+// Aborts every incomplete multipart upload in a bucket, returning how many were aborted. Their
+// parts are otherwise kept (and billed) until the upload is completed or aborted.
+func(c *Client) AbortMultipartUploads(ctx context.Context, bucketName string) (int, error) {
+    uploads := []*s3.MultipartUpload{}
+
+    err := c.svc.ListMultipartUploadsPagesWithContext(ctx, &s3.ListMultipartUploadsInput{
         Bucket: aws.String(bucketName),
+    }, func(page *s3.ListMultipartUploadsOutput, lastPage bool) bool {
+        uploads = append(uploads, page.Uploads...)
+        return !lastPage
     })
 
-    return s3manager.NewBatchDeleteWithClient(c.svc).Delete(aws.BackgroundContext(), iter)
+    if err != nil {
+        return 0, err
+    }
+
+    for i, upload := range uploads {
+        _, err := c.svc.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+            Bucket:   aws.String(bucketName),
+            Key:      upload.Key,
+            UploadId: upload.UploadId,
+        })
+
+        // Uploads that completed or were aborted since the listing are already gone.
+        if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchUpload {
+            continue
+        }
+
+        if err != nil {
+            return i, err
+        }
+    }
+
+    return len(uploads), nil
 }
 
-// Executes RestoreObject API operation on a single S3 key.
-func(c *Client) RestoreObject(bucketName, key, requestPayer string) error {
-    _, err := c.svc.RestoreObject(&s3.RestoreObjectInput{
-        Bucket:       aws.String(bucketName),
-        Key:          aws.String(key),
-        RequestPayer: aws.String(requestPayer),
-    })
+// Looks up the region of a bucket. Results are cached per bucket.
+func(c *Client) GetBucketRegion(bucketName string) (string, error) {
+    return c.s3.BucketRegion(bucketName)
+}
 
-    return err
+// Same as GetBucketRegion, but for a bucket that objects are transferred from. Uses the
+// source options when the client has them.
+func(c *Client) GetSourceBucketRegion(bucketName string) (string, error) {
+    if c.source != nil {
+        return c.source.BucketRegion(bucketName)
+    }
+    return c.s3.BucketRegion(bucketName)
 }
 
 // Executes DeleteObject API operation on a single S3 key.
-func(c *Client) DeleteObject(bucketName, key, requestPayer string) error {
-    _, err := c.svc.DeleteObject(&s3.DeleteObjectInput{
-        Bucket:       aws.String(bucketName),
-        Key:          aws.String(key),
-        RequestPayer: aws.String(requestPayer),
-    })
+func(c *Client) DeleteObject(ctx context.Context, bucketName, key, requestPayer string) error {
+    return c.s3.Delete(ctx, ObjectInfo{Bucket: &bucketName, Key: &key}, requestPayer)
+}
 
-    return err
+// Deletes a single object from whichever backend it was listed from.
+func(c *Client) RemoveObject(ctx context.Context, object ObjectInfo, requestPayer string) error {
+    return c.objectBackend(object).Delete(ctx, object, requestPayer)
 }
 
-// TODO(scruwys)
-// https://github.com/awsdocs/aws-doc-sdk-examples/blob/master/go/example_code/s3/s3_download_object.go
-func(c *Client) DownloadObject(object ObjectInfo, targetPrefix string, options *MoveObjectOptions) (string, error) {
-    sourcePath := fmt.Sprintf("s3://%s/%s", options.Source.Bucket, *object.Key)
-    targetPath := fmt.Sprintf("%s", targetPrefix)
-    logMessage := fmt.Sprintf("%s to %s", sourcePath, targetPath)
+// Deletes many objects, in batches where the backend supports it. Returns the error for each
+// object that couldn't be deleted (nil otherwise), in the same order as objects.
+func(c *Client) RemoveObjects(ctx context.Context, objects []ObjectInfo, requestPayer string) []error {
+    errs := make([]error, len(objects))
+
+    batches := make(map[Backend][]int)
+
+    for i, object := range objects {
+        backend := c.objectBackend(object)
+        batches[backend] = append(batches[backend], i)
+    }
+
+    for backend, indexes := range batches {
+        deleter, ok := backend.(BatchDeleter)
+
+        // A single key doesn't need the overhead of a batch request.
+        if !ok || len(indexes) == 1 {
+            for _, index := range indexes {
+                errs[index] = backend.Delete(ctx, objects[index], requestPayer)
+            }
+            continue
+        }
+
+        batch := make([]ObjectInfo, len(indexes))
+        for i, index := range indexes {
+            batch[i] = objects[index]
+        }
+
+        for i, err := range deleter.DeleteBatch(ctx, batch, requestPayer) {
+            errs[indexes[i]] = err
+        }
+    }
+
+    return errs
+}
+
+// Downloads an object from its backend into a local file. It is written to a partial file next to
+// the target first and only renamed once complete, so an interrupted download never leaves a
+// truncated file behind. With options.Resume, partial files are kept and picked up again later.
+func(c *Client) DownloadObject(ctx context.Context, object ObjectInfo, targetPrefix string, options *MoveObjectOptions) (string, error) {
+    logMessage := transferMessage(object, targetPrefix, options)
 
     if options.DryRun {
         return logMessage, nil
     }
 
-    file, err := createFile(targetPrefix)
+    dir, _ := filepath.Split(targetPrefix)
 
-    if err != nil {
+    if err := ensureDir(dir); err != nil {
         return "", err
     }
 
-    defer file.Close()
+    source := c.sourceBackend(options.Source)
+    ranger, resume := source.(RangeGetter)
 
-    _, err = c.downloader.Download(file, &s3.GetObjectInput{
-        Bucket:       aws.String(options.Source.Bucket),
-        Key:          aws.String(*object.Key),
-        RequestPayer: aws.String(options.RequestPayer),
-    })
+    // Without an ETag there is no telling whether a partial file still belongs to the same object.
+    resume = resume && options.Resume && aws.StringValue(object.ETag) != "" && object.Size != nil
+
+    partialPath := partialDownloadPath(targetPrefix, object)
+    flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+
+    if resume {
+        flags = os.O_RDWR | os.O_CREATE
+        removeStalePartialDownloads(targetPrefix, partialPath)
+    }
+
+    file, err := os.OpenFile(partialPath, flags, 0666)
 
     if err != nil {
         return "", err
     }
 
-    if options.DeleteAfter {
-        err = c.DeleteObject(options.Source.Bucket, *object.Key, options.RequestPayer)
+    if resume {
+        err = resumeDownload(ctx, ranger, object, file, options)
+    } else {
+        var w io.WriterAt = file
 
-        if err != nil {
+        if options.Progress != nil {
+            w = &progressWriterAt{w: file, tracker: options.Progress.newTracker()}
+        }
+
+        err = source.Get(ctx, object, w, options)
+    }
+
+    if closeErr := file.Close(); err == nil {
+        err = closeErr
+    }
+
+    if err == nil {
+        err = os.Rename(partialPath, targetPrefix)
+    }
+
+    if err != nil {
+        if !resume {
+            os.Remove(partialPath)
+        }
+        return "", err
+    }
+
+    // Matching the local timestamp to S3 lets later syncs tell that the file is up to date.
+    if object.LastModified != nil && !object.LastModified.IsZero() {
+        if err = os.Chtimes(targetPrefix, time.Now(), *object.LastModified); err != nil {
             return "", err
         }
     }
@@ -202,18 +399,55 @@ func(c *Client) DownloadObject(object ObjectInfo, targetPrefix string, options *
     return logMessage, nil
 }
 
-// TODO(scruwys)
-// https://github.com/awsdocs/aws-doc-sdk-examples/blob/master/go/example_code/s3/s3_upload_object.go
-func(c *Client) UploadObject(object ObjectInfo, targetPrefix string, options *MoveObjectOptions) (string, error) {
-    sourcePath := fmt.Sprintf("%s", object.Path())
-    targetPath := fmt.Sprintf("s3://%s/%s", options.Target.Bucket, targetPrefix)
-    logMessage := fmt.Sprintf("%s to %s", sourcePath, targetPath)
+// Appends whatever is missing from a partial download. A partial file that is somehow longer
+// than the object is started over.
+func resumeDownload(ctx context.Context, ranger RangeGetter, object ObjectInfo, file *os.File, options *MoveObjectOptions) error {
+    info, err := file.Stat()
+
+    if err != nil {
+        return err
+    }
+
+    offset := info.Size()
+
+    if offset > *object.Size {
+        if err = file.Truncate(0); err != nil {
+            return err
+        }
+        offset = 0
+    }
+
+    if _, err = file.Seek(offset, io.SeekStart); err != nil {
+        return err
+    }
+
+    tracker := options.Progress.newTracker()
+
+    // Bytes from an earlier run count as done, so the totals still add up.
+    tracker.add(0, offset)
+
+    if offset == *object.Size {
+        return nil
+    }
+
+    var w io.Writer = file
+
+    if tracker != nil {
+        w = &progressStreamWriter{w: file, tracker: tracker, offset: offset}
+    }
+
+    return ranger.GetRange(ctx, object, w, offset, options)
+}
+
+// Uploads a local file into the target backend.
+func(c *Client) UploadObject(ctx context.Context, object ObjectInfo, targetPrefix string, options *MoveObjectOptions) (string, error) {
+    logMessage := transferMessage(object, targetPrefix, options)
 
     if options.DryRun {
         return logMessage, nil
     }
 
-    file, err := os.Open(sourcePath)
+    file, err := os.Open(*object.Key)
 
     if err != nil {
         return "", err
@@ -221,82 +455,165 @@ func(c *Client) UploadObject(object ObjectInfo, targetPrefix string, options *Mo
 
     defer file.Close()
 
-    _, err = c.uploader.Upload(&s3manager.UploadInput{
-        Bucket:             aws.String(options.Target.Bucket),
-        Key:                aws.String(targetPrefix),
-        Body:               file,
-        ACL:                aws.String(options.ACL),
-        ContentDisposition: aws.String(options.ContentDisposition),
-        ContentEncoding:    aws.String(options.ContentEncoding),
-        ContentType:        aws.String(options.ContentType),
-        ContentLanguage:    aws.String(options.ContentLanguage),
-        RequestPayer:       aws.String(options.RequestPayer),
-    })
+    var r io.Reader = file
 
-    if err != nil {
-        return "", err
+    if options.Progress != nil {
+        r = &progressReader{file: file, tracker: options.Progress.newTracker()}
     }
 
-    if options.DeleteAfter {
-        err = os.Remove(sourcePath)
+    options = withGuessedContentType(options, *object.Key, file)
 
-        if err != nil {
-            return "", err
-        }
+    if err = c.Backend(options.Target).Put(ctx, options.Target.Bucket, targetPrefix, r, options); err != nil {
+        return "", err
     }
 
     return logMessage, nil
 }
 
-// TODO(scruwys)
-// https://github.com/awsdocs/aws-doc-sdk-examples/blob/master/go/example_code/s3/s3_copy_object.go
-func(c *Client) CopyObject(object ObjectInfo, targetPrefix string, options *MoveObjectOptions) (string, error) {
-    sourcePath := fmt.Sprintf("s3://%s", object.Path())
-    targetPath := fmt.Sprintf("s3://%s/%s", options.Target.Bucket, targetPrefix)
+// Copies an object within a single backend, e.g. S3 to S3 or between two local paths.
+func(c *Client) CopyObject(ctx context.Context, object ObjectInfo, targetPrefix string, options *MoveObjectOptions) (string, error) {
+    logMessage := transferMessage(object, targetPrefix, options)
+
+    if options.DryRun {
+        return logMessage, nil
+    }
+
+    if err := c.Backend(options.Target).Copy(ctx, object, options.Target.Bucket, targetPrefix, options); err != nil {
+        return "", err
+    }
+
+    // Copies happen in one go, so the whole object counts once it's done.
+    if options.Progress != nil && object.Size != nil {
+        options.Progress.newTracker().add(0, *object.Size)
+    }
+
+    return logMessage, nil
+}
 
-    logMessage := fmt.Sprintf("%s to %s", sourcePath, targetPath)
+// Transfers an object between two different non-local backends by piping the download into the upload.
+func(c *Client) StreamObject(ctx context.Context, object ObjectInfo, targetPrefix string, options *MoveObjectOptions) (string, error) {
+    logMessage := transferMessage(object, targetPrefix, options)
 
     if options.DryRun {
         return logMessage, nil
     }
 
-    _, err := c.svc.CopyObject(&s3.CopyObjectInput{
-        CopySource:         aws.String(object.Path()),
-        Bucket:             aws.String(options.Target.Bucket),
-        Key:                aws.String(targetPrefix),
-        ACL:                aws.String(options.ACL),
-        ContentDisposition: aws.String(options.ContentDisposition),
-        ContentEncoding:    aws.String(options.ContentEncoding),
-        ContentType:        aws.String(options.ContentType),
-        ContentLanguage:    aws.String(options.ContentLanguage),
-        RequestPayer:       aws.String(options.RequestPayer),
-    })
+    source := c.sourceBackend(options.Source)
+
+    // The upload is a new object, so whatever the source has that a copy would keep is set on it.
+    if getter, ok := source.(MetadataGetter); ok {
+        var err error
+
+        if options, err = getter.GetMetadata(ctx, object, options); err != nil {
+            return "", err
+        }
+    }
+
+    reader, writer := io.Pipe()
+
+    go func() {
+        writer.CloseWithError(source.Get(ctx, object, &sequentialWriterAt{w: writer}, options))
+    }()
+
+    var r io.Reader = reader
+
+    if options.Progress != nil {
+        r = &progressStreamReader{r: reader, tracker: options.Progress.newTracker()}
+    }
+
+    err := c.Backend(options.Target).Put(ctx, options.Target.Bucket, targetPrefix, r, options)
+
+    // Unblocks the download if the upload gave up early.
+    reader.CloseWithError(err)
 
     if err != nil {
         return "", err
     }
 
-    err = c.svc.WaitUntilObjectExists(&s3.HeadObjectInput{
-        Bucket:       aws.String(options.Target.Bucket),
-        Key:          aws.String(targetPrefix),
-        RequestPayer: aws.String(options.RequestPayer),
-    })
+    return logMessage, nil
+}
 
-    if err != nil {
+// Uploads everything read from r, e.g. stdin, to a single key in the target backend. The size
+// isn't known up front, so it's uploaded in parts as it's read.
+func(c *Client) UploadStream(ctx context.Context, r io.Reader, targetKey string, options *MoveObjectOptions) (string, error) {
+    logMessage := fmt.Sprintf("%s to %s", STREAM_PATH, options.Target.ObjectUrl(targetKey))
+
+    if options.DryRun {
+        return logMessage, nil
+    }
+
+    if options.Progress != nil {
+        r = &progressStreamReader{r: r, tracker: options.Progress.newTracker()}
+    }
+
+    // A stream can't be sniffed without consuming it, so only the target's extension is used.
+    options = withGuessedContentType(options, targetKey, nil)
+
+    if err := c.Backend(options.Target).Put(ctx, options.Target.Bucket, targetKey, r, options); err != nil {
         return "", err
     }
 
-    if options.DeleteAfter {
-        err = c.DeleteObject(*object.Bucket, *object.Key, options.RequestPayer)
+    return logMessage, nil
+}
 
-        if err != nil {
-            return "", err
-        }
+// Writes an object to w from start to finish, e.g. to stdout.
+func(c *Client) DownloadStream(ctx context.Context, object ObjectInfo, w io.Writer, options *MoveObjectOptions) (string, error) {
+    logMessage := fmt.Sprintf("%s to %s", object.Url(), STREAM_PATH)
+
+    if options.DryRun {
+        return logMessage, nil
+    }
+
+    if options.Progress != nil {
+        w = &progressStreamWriter{w: w, tracker: options.Progress.newTracker()}
+    }
+
+    if err := c.sourceBackend(options.Source).Get(ctx, object, &sequentialWriterAt{w: w}, options); err != nil {
+        return "", err
     }
 
     return logMessage, nil
 }
 
+// Copies an object between two sets of credentials on the same endpoint. The copy is made with
+// the destination's credentials, so it only works server-side if they can also read the source.
+// Otherwise the object is streamed through s3go instead.
+func(c *Client) copyOrStreamObject(ctx context.Context, object ObjectInfo, targetPrefix string, options *MoveObjectOptions) (string, error) {
+    logMessage, err := c.CopyObject(ctx, object, targetPrefix, options)
+
+    if isAccessDenied(err) {
+        return c.StreamObject(ctx, object, targetPrefix, options)
+    }
+
+    return logMessage, err
+}
+
+// Whether S3 refused a request with a 403. HEAD responses have no body, so those come back as
+// "Forbidden" rather than "AccessDenied". Archived objects are refused with a 403 too, but
+// streaming them wouldn't help.
+func isAccessDenied(err error) bool {
+    rerr, ok := err.(awserr.RequestFailure)
+    return ok && rerr.StatusCode() == http.StatusForbidden && rerr.Code() != "InvalidObjectState"
+}
+
+// Whether two backends are S3 backends talking to the same endpoint, so a server-side copy may work.
+func sharesEndpoint(source, target Backend) bool {
+    sourceS3, ok := source.(*S3Backend)
+
+    if !ok {
+        return false
+    }
+
+    targetS3, ok := target.(*S3Backend)
+
+    return ok && sourceS3.svc.Endpoint == targetS3.svc.Endpoint
+}
+
+// Describes a transfer as "<source> to <target>".
+func transferMessage(object ObjectInfo, targetPrefix string, options *MoveObjectOptions) string {
+    return fmt.Sprintf("%s to %s", object.Url(), options.Target.ObjectUrl(targetPrefix))
+}
+
 type MoveObjectOptions struct {
     Target             *S3Url
     Source             *S3Url
@@ -305,28 +622,144 @@ type MoveObjectOptions struct {
     ContentEncoding    string
     ContentLanguage    string
     ContentType        string
+    CacheControl       string
+
+    // Guess the content type of uploads from the file extension when ContentType isn't set
+    GuessMimeType      bool
+
+    // When guessing, sniff the first bytes of files whose extension isn't known. Off by default,
+    // since a wrong guess is worse than S3's binary/octet-stream and every such file is read twice.
+    SniffContentType   bool
+
+    // Extensions (with the leading dot) mapped to content types, taking precedence over the
+    // system's when guessing
+    MimeTypes          map[string]string
+    CompareMode        CompareMode
     DeleteAfter        bool
     DryRun             bool
     Recursive          bool
     RequestPayer       string
+
+    // Storage class for new objects. Left to S3 when empty.
+    StorageClass       string
+
+    // User-defined metadata, sent as x-amz-meta-* headers
+    Metadata           map[string]string
+
+    // Whether copies keep the source's metadata and content headers (COPY, the default) or
+    // replace them with the ones in these options (REPLACE)
+    MetadataDirective  string
+
+    // Tags for new objects, encoded as a query string (e.g. "team=data&env=prod"). Copies keep
+    // the source's tags when empty.
+    Tagging            string
+
+    // When the object can no longer be cached
+    Expires            *time.Time
+
+    // Redirects requests for the object to another object or URL when the bucket is a website
+    WebsiteRedirect    string
+
+    // Server-side encryption for new objects, and the customer keys to read SSE-C objects with
+    Encryption         EncryptionOptions
+
+    // Keep partial downloads around and continue them with ranged GETs on the next run
+    Resume             bool
+
+    // Expected size of an upload from a stream, used to pick a part size that keeps it under
+    // S3's limit of 10,000 parts. Optional.
+    ExpectedSize       int64
+
+    // Reports bytes as they are transferred. Optional.
+    Progress           *Progress
+}
+
+// Resolves the key (or local path) that a source object will be written to.
+func resolveTargetKey(object ObjectInfo, source, target *S3Url, recursive bool) string {
+    sourcePrefix := source.Prefix
+
+    if source.IsLocal() && recursive {
+        sourcePrefix = cleanLocalPrefix(sourcePrefix)
+    }
+
+    return buildTargetPrefix(target.Prefix, sourcePrefix, *object.Key, recursive)
 }
 
-func(c *Client) MoveObject(object ObjectInfo, options *MoveObjectOptions) (string, error) {
-    targetPrefix := buildTargetPrefix(options.Target.Prefix, options.Source.Prefix, *object.Key, options.Recursive)
+func(c *Client) MoveObject(ctx context.Context, object ObjectInfo, options *MoveObjectOptions) (string, error) {
+    targetPrefix := resolveTargetKey(object, options.Source, options.Target, options.Recursive)
+    unchanged := false
+
+    if options.CompareMode != COMPARE_DEFAULT {
+        targetObject, err := c.Backend(options.Target).Stat(ctx, options.Target.Bucket, targetPrefix, options)
+
+        if err != nil {
+            return "", err
+        }
+
+        if targetObject != nil {
+            modified, err := c.IsObjectModified(object, *targetObject, options.CompareMode)
+
+            if err != nil {
+                return "", err
+            }
+
+            // A move still has to remove the source, unless the destination is the source itself.
+            if !modified && (!options.DeleteAfter || c.isSameObject(object, targetPrefix, options)) {
+                return "", ErrObjectUnchanged
+            }
+
+            unchanged = !modified
+        }
+    }
+
+    source := c.sourceBackend(options.Source)
+    target := c.Backend(options.Target)
+
+    var logMessage string
+    var err error
+
+    switch {
+    case unchanged:
+        logMessage = transferMessage(object, targetPrefix, options)
+    case source == target:
+        logMessage, err = c.CopyObject(ctx, object, targetPrefix, options)
+    case options.Target.IsLocal():
+        logMessage, err = c.DownloadObject(ctx, object, targetPrefix, options)
+    case options.Source.IsLocal():
+        logMessage, err = c.UploadObject(ctx, object, targetPrefix, options)
+    case sharesEndpoint(source, target):
+        logMessage, err = c.copyOrStreamObject(ctx, object, targetPrefix, options)
+    default:
+        logMessage, err = c.StreamObject(ctx, object, targetPrefix, options)
+    }
+
+    if err != nil || options.DryRun || !options.DeleteAfter {
+        return logMessage, err
+    }
+
+    // Moving an object onto itself, e.g. to change its storage class, has nothing left to remove.
+    if c.isSameObject(object, targetPrefix, options) {
+        return logMessage, nil
+    }
 
-    if !options.Source.IsLocal() && options.Target.IsLocal() {
-        return c.DownloadObject(object, targetPrefix, options)
+    if err = source.Delete(ctx, object, options.RequestPayer); err != nil {
+        return "", err
     }
 
-    if !options.Source.IsLocal() && !options.Target.IsLocal() {
-        return c.CopyObject(object, targetPrefix, options)
+    return logMessage, nil
+}
+
+// Whether the target key is the object itself, e.g. when a file is moved onto itself.
+func(c *Client) isSameObject(object ObjectInfo, targetPrefix string, options *MoveObjectOptions) bool {
+    if c.sourceBackend(options.Source) != c.Backend(options.Target) {
+        return false
     }
 
-    if options.Source.IsLocal() && !options.Target.IsLocal() {
-        return c.UploadObject(object, targetPrefix, options)
+    if object.IsLocal() {
+        return sameFile(*object.Key, targetPrefix)
     }
 
-    return "", errors.New("This action is not supported.")
+    return *object.Bucket == options.Target.Bucket && *object.Key == targetPrefix
 }
 
 type ObjectInfo struct {
@@ -352,6 +785,40 @@ type ObjectInfo struct {
 
     // The name of the Amazon S3 bucket
     Bucket *string `type:"string"`
+
+    // Version ID of the object. Only set for objects listed or looked up by version.
+    VersionId *string `type:"string"`
+
+    // Whether the version is the current version of the object
+    IsLatest bool
+
+    // Whether the version is a delete marker rather than an object
+    IsDeleteMarker bool
+
+    // Set on the last item of a listing that failed part way through, instead of an object. Bucket
+    // and Key are then the bucket and prefix that were being listed.
+    Err error
+
+    // The backend the object was listed from
+    backend Backend
+}
+
+// The item a listing of bucket and prefix ends with when it fails.
+func listingError(bucket, prefix string, err error) ObjectInfo {
+    return ObjectInfo{Bucket: &bucket, Key: &prefix, Err: err}
+}
+
+// Indicates whether or not the ObjectInfo represents a file on the local file system.
+func (o *ObjectInfo) IsLocal() bool {
+    return *o.Bucket == ""
+}
+
+// The S3 URL of the object, or its path if it's a local file.
+func (o *ObjectInfo) Url() string {
+    if o.IsLocal() {
+        return *o.Key
+    }
+    return fmt.Sprintf("s3://%s", o.Path())
 }
 
 func (o *ObjectInfo) Path() string {
@@ -372,104 +839,34 @@ type ListObjectsV2Input struct {
     // List all files or objects under the specified directory or prefix
     Recursive bool
 
-    // Don't exclude files or objects in the command that match the specified pattern
-    ExcludeFilter string
+    // When not recursive, list one level below the prefix like a directory instead of
+    // treating the prefix as the key of a single object
+    Directory bool
+
+    // --include and --exclude rules that decide which files or objects are kept
+    Filters Filters
 
-    // Exclude all files or objects from the command that matches the specified pattern
-    IncludeFilter string
+    // When the prefix is a single object, look up this version of it instead of the current one
+    VersionId string
 
+    // Customer key to look up a single object encrypted with SSE-C
+    CustomerKey []byte
 }
 
 // List objects in an S3 bucket and prefix using the list-objects-v2 API method.
-func(c *Client) ListObjectsV2(options *ListObjectsV2Input) (ch <-chan ObjectInfo, err error) {
-    delimiter := ""
-    if !options.Recursive {
-        delimiter = "/"
-    }
-
-    region, err := GetBucketRegion(options.Bucket)
-
-    if err != nil {
-        return nil, err
-    }
-
-    // We override the session to list objects from whatever target bucket.
-    svc := s3.New(c.sess, NewConfig(&ClientOptions{
-        Endpoint: fmt.Sprintf("s3.%s.amazonaws.com", region),
-        Region:   region,
-    }))
-
-    if !options.Recursive {
-        emptyCh := make(chan ObjectInfo)
-        defer close(emptyCh)
-
-        if options.Prefix == "" {
-            return emptyCh, nil
-        }
-
-        _, err := svc.HeadObject(&s3.HeadObjectInput{
-            Bucket: aws.String(options.Bucket),
-            Key:    aws.String(options.Prefix),
-        })
-
-        if err != nil {
-            return nil, err
-        }
-    }
+func(c *Client) ListObjectsV2(ctx context.Context, options *ListObjectsV2Input) (ch <-chan ObjectInfo, err error) {
+    return listFrom(ctx, c.s3, options)
+}
 
-    input := &s3.ListObjectsV2Input{
-        Bucket:    aws.String(options.Bucket),
-        Prefix:    aws.String(options.Prefix),
-        Delimiter: aws.String(delimiter),
-    }
+// List every version of the objects in an S3 bucket and prefix, including delete markers.
+func(c *Client) ListObjectVersions(ctx context.Context, options *ListObjectsV2Input) (ch <-chan ObjectInfo, err error) {
+    versionCh, err := c.s3.ListVersions(ctx, options)
 
-    excludeRe, err := RegexpCompile(options.ExcludeFilter, "$^")
     if err != nil {
         return nil, err
     }
 
-    includeRe, err := RegexpCompile(options.IncludeFilter, ".*")
-    if err != nil {
-        return nil, err
-    }
-
-    outputCh := make(chan ObjectInfo)
-
-    go func() {
-        defer close(outputCh)
-
-        svc.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
-            for _, prefix := range page.CommonPrefixes {
-                objectInfo := ObjectInfo{
-                    Bucket:       &options.Bucket,
-                    Key:          prefix.Prefix,
-                    IsPrefix:     true,
-                    Size:         new(int64),
-                    LastModified: &time.Time{},
-                }
-                outputCh <- objectInfo
-            }
-
-            for _, object := range page.Contents {
-                if excludeRe.MatchString(*object.Key) || !includeRe.MatchString(*object.Key) {
-                    continue
-                }
-                objectKey := *object.Key
-                objectInfo := ObjectInfo{
-                    Bucket:       &options.Bucket,
-                    Key:          object.Key,
-                    IsPrefix:     objectKey[len(objectKey)-1:] == "/",
-                    Size:         object.Size,
-                    LastModified: object.LastModified,
-                }
-                outputCh <- objectInfo
-            }
-
-            return !lastPage
-        })
-    }()
-
-    return outputCh, nil
+    return withBackend(ctx, c.s3, versionCh), nil
 }
 
 type ListSourceObjectsInput struct {
@@ -479,26 +876,26 @@ type ListSourceObjectsInput struct {
     // List all files or objects under the specified directory or prefix
     Recursive bool
 
-    // Don't exclude files or objects in the command that match the specified pattern
-    ExcludeFilter string
+    // --include and --exclude rules that decide which files or objects are kept
+    Filters Filters
 
-    // Exclude all files or objects from the command that matches the specified pattern
-    IncludeFilter string
-}
+    // Use a specific version of a single source object
+    VersionId string
 
-// List source objects from either S3 or the local file system. Used for "cp" and "mv" commands, etc.
-func(c *Client) ListSourceObjects(options *ListSourceObjectsInput) (ch <-chan ObjectInfo, err error) {
-    if options.SourceUrl.IsLocal() {
-        return listFiles(options.SourceUrl.Prefix, options.Recursive, options.ExcludeFilter, options.IncludeFilter)
-    }
+    // Customer key to look up a single source object encrypted with SSE-C
+    CustomerKey []byte
+}
 
+// List source objects from whichever backend holds them. Used for "cp" and "mv" commands, etc.
+func(c *Client) ListSourceObjects(ctx context.Context, options *ListSourceObjectsInput) (ch <-chan ObjectInfo, err error) {
     input := &ListObjectsV2Input{
-        Bucket:        options.SourceUrl.Bucket,
-        Prefix:        options.SourceUrl.Prefix,
-        Recursive:     options.Recursive,
-        ExcludeFilter: options.ExcludeFilter,
-        IncludeFilter: options.IncludeFilter,
+        Bucket:      options.SourceUrl.Bucket,
+        Prefix:      options.SourceUrl.Prefix,
+        Recursive:   options.Recursive,
+        Filters:     options.Filters,
+        VersionId:   options.VersionId,
+        CustomerKey: options.CustomerKey,
     }
 
-    return c.ListObjectsV2(input)
+    return listFrom(ctx, c.sourceBackend(options.SourceUrl), input)
 }
@@ -1,9 +1,137 @@
 package s3go
 
 import (
+    "context"
+    "io/ioutil"
+    "net/http"
+    "os"
+    "path/filepath"
     "testing"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/awserr"
+    "github.com/scruwys/s3go/internal/s3test"
 )
 
 func TestNewClient(t *testing.T) {
 
 }
+
+func TestNewConfigNoVerifySSL(t *testing.T) {
+    cfg := NewConfig(&ClientOptions{NoVerifySSL: true})
+
+    // Skipping certificate checks must not fall back to plain HTTP.
+    if aws.BoolValue(cfg.DisableSSL) {
+        t.Error("got DisableSSL, want requests to still use HTTPS")
+    }
+
+    transport, ok := cfg.HTTPClient.Transport.(*http.Transport)
+
+    if !ok || transport.TLSClientConfig == nil || !transport.TLSClientConfig.InsecureSkipVerify {
+        t.Errorf("got transport %#v, want one that skips certificate verification", cfg.HTTPClient.Transport)
+    }
+
+    if cfg := NewConfig(&ClientOptions{}); cfg.HTTPClient != nil {
+        t.Error("got a custom HTTP client, want the SDK's default that verifies certificates")
+    }
+}
+
+// Routes all HTTP traffic to the fake server for the rest of the test.
+func useTestServer(t *testing.T, server *s3test.Server) func() {
+    transport := http.DefaultTransport
+    http.DefaultTransport = server.Transport()
+
+    // Sessions created with AWS_CA_BUNDLE set install their own transport on http.DefaultClient.
+    clientTransport := http.DefaultClient.Transport
+    http.DefaultClient.Transport = nil
+
+    os.Setenv("AWS_ACCESS_KEY_ID", "AKIAS3GOTESTING")
+    os.Setenv("AWS_SECRET_ACCESS_KEY", "s3go-testing-secret")
+    os.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+    os.Setenv("AWS_CONFIG_FILE", os.DevNull)
+    os.Unsetenv("AWS_CA_BUNDLE")
+
+    return func() {
+        http.DefaultTransport = transport
+        http.DefaultClient.Transport = clientTransport
+    }
+}
+
+func TestGetBucketRegion(t *testing.T) {
+    server := s3test.NewServer()
+    defer server.Close()
+    defer useTestServer(t, server)()
+
+    server.Region = "eu-west-1"
+    server.CreateBucket("s3go-region")
+
+    client := NewClient(&ClientOptions{Endpoint: server.URL, Region: "us-east-1", DisableSSL: true})
+
+    region, err := client.GetBucketRegion("s3go-region")
+    if err != nil || region != "eu-west-1" {
+        t.Fatalf("got %s (%v), want eu-west-1", region, err)
+    }
+
+    // Regions are cached, so later changes on the server go unnoticed.
+    server.Region = "ap-south-1"
+
+    if region, _ := client.GetBucketRegion("s3go-region"); region != "eu-west-1" {
+        t.Errorf("got %s, want the cached eu-west-1", region)
+    }
+
+    svc, err := client.s3.serviceForBucket("s3go-region")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if svc.Endpoint != server.URL || aws.StringValue(svc.Config.Region) != "eu-west-1" {
+        t.Errorf("got endpoint %s in %s, want %s in eu-west-1", svc.Endpoint, aws.StringValue(svc.Config.Region), server.URL)
+    }
+
+    if _, err := client.GetBucketRegion("s3go-missing"); err == nil {
+        t.Errorf("expected an error for a bucket that doesn't exist")
+    }
+}
+
+func TestDownloadObjectResumeChangedObject(t *testing.T) {
+    server := s3test.NewServer()
+    defer server.Close()
+    defer useTestServer(t, server)()
+
+    listed := server.PutObject("s3go-resume", "backup.tar", []byte("0123456789abcdef"))
+    etag, size, bucket, key := listed.ETag, int64(len(listed.Body)), "s3go-resume", "backup.tar"
+
+    dir, err := ioutil.TempDir("", "s3go-resume")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    target := filepath.Join(dir, "backup.tar")
+    object := ObjectInfo{Bucket: &bucket, Key: &key, ETag: &etag, Size: &size}
+    partial := partialDownloadPath(target, object)
+    writeTestFile(t, partial, "01234567")
+
+    // The object is replaced between the listing and the download.
+    server.PutObject("s3go-resume", "backup.tar", []byte("replaced"))
+
+    client := NewClient(&ClientOptions{Endpoint: server.URL, DisableSSL: true})
+    _, err = client.DownloadObject(context.Background(), object, target, &MoveObjectOptions{
+        Source: &S3Url{Scheme: "s3", Bucket: bucket},
+        Target: &S3Url{Prefix: target},
+        Resume: true,
+    })
+
+    if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "PreconditionFailed" {
+        t.Fatalf("got %v, want PreconditionFailed", err)
+    }
+
+    // Nothing is renamed into place, and the partial file is left for a later run to sort out.
+    if _, err := os.Stat(target); !os.IsNotExist(err) {
+        t.Errorf("expected no file at %s", target)
+    }
+
+    if body, _ := ioutil.ReadFile(partial); string(body) != "01234567" {
+        t.Errorf("got partial file %q, want it untouched", body)
+    }
+}
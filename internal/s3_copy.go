@@ -0,0 +1,328 @@
+package s3go
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strings"
+    "sync"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/awserr"
+    "github.com/aws/aws-sdk-go/service/s3"
+    "github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// The largest object S3 copies with a single CopyObject request.
+const MAX_COPY_OBJECT_SIZE int64 = 5 * 1024 * 1024 * 1024
+
+const (
+    // Copies keep the metadata and content headers of the source object
+    METADATA_DIRECTIVE_COPY = "COPY"
+
+    // Copies take their metadata and content headers from the request instead
+    METADATA_DIRECTIVE_REPLACE = "REPLACE"
+)
+
+func ValidateMetadataDirective(directive string) error {
+    switch directive {
+    case "", METADATA_DIRECTIVE_COPY, METADATA_DIRECTIVE_REPLACE:
+        return nil
+    }
+    return fmt.Errorf("Unknown metadata directive %q. Expected one of: %s, %s", directive, METADATA_DIRECTIVE_COPY, METADATA_DIRECTIVE_REPLACE)
+}
+
+// Parses --metadata, a comma separated list of key=value pairs.
+func ParseMetadata(value string) (map[string]string, error) {
+    if value == "" {
+        return nil, nil
+    }
+
+    metadata := make(map[string]string)
+
+    for _, pair := range strings.Split(value, ",") {
+        parts := strings.SplitN(pair, "=", 2)
+
+        if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+            return nil, fmt.Errorf("--metadata must be a comma separated list of key=value pairs, got %q.", value)
+        }
+
+        metadata[strings.TrimSpace(parts[0])] = parts[1]
+    }
+
+    return metadata, nil
+}
+
+// Checks that --tagging is a query string of tags, the way the x-amz-tagging header expects them.
+func ValidateTagging(tagging string) error {
+    if _, err := url.ParseQuery(tagging); err != nil {
+        return fmt.Errorf("--tagging must be URL encoded key=value pairs joined by &, e.g. team=data&env=prod: %v", err)
+    }
+    return nil
+}
+
+// Sets the metadata and content headers of a copy. With COPY, S3 ignores them and keeps the
+// source's, so they are only sent with REPLACE. Empty values are never sent, since they would
+// clear the header instead of leaving it alone.
+func applyReplacedMetadata(input *s3.CopyObjectInput, options *MoveObjectOptions) {
+    if options.MetadataDirective != METADATA_DIRECTIVE_REPLACE {
+        return
+    }
+
+    input.MetadataDirective = aws.String(METADATA_DIRECTIVE_REPLACE)
+    input.CacheControl = nonEmptyString(options.CacheControl)
+    input.ContentDisposition = nonEmptyString(options.ContentDisposition)
+    input.ContentEncoding = nonEmptyString(options.ContentEncoding)
+    input.ContentLanguage = nonEmptyString(options.ContentLanguage)
+    input.ContentType = nonEmptyString(options.ContentType)
+    input.Expires = options.Expires
+
+    if len(options.Metadata) > 0 {
+        input.Metadata = aws.StringMap(options.Metadata)
+    }
+}
+
+// Objects larger than this are copied in parts.
+func(b *S3Backend) multipartThreshold() int64 {
+    if b.options.MultipartThreshold > 0 && b.options.MultipartThreshold < MAX_COPY_OBJECT_SIZE {
+        return b.options.MultipartThreshold
+    }
+    return MAX_COPY_OBJECT_SIZE
+}
+
+// Copies an object with UploadPartCopy, several parts at a time. CopyObject keeps the metadata,
+// content headers and tags of the source, so they are read from the source and set on the upload.
+func(b *S3Backend) copyMultipart(ctx context.Context, object ObjectInfo, bucket, key string, options *MoveObjectOptions) error {
+    source, err := b.sourceBackend(object).GetMetadata(ctx, object, options)
+
+    if err != nil {
+        return err
+    }
+
+    algorithm, customerKey := customerKeyParams(options.Encryption.CustomerKey)
+
+    input := &s3.CreateMultipartUploadInput{
+        Bucket:                  aws.String(bucket),
+        Key:                     aws.String(key),
+        ACL:                     nonEmptyString(options.ACL),
+        CacheControl:            nonEmptyString(source.CacheControl),
+        ContentDisposition:      nonEmptyString(source.ContentDisposition),
+        ContentEncoding:         nonEmptyString(source.ContentEncoding),
+        ContentLanguage:         nonEmptyString(source.ContentLanguage),
+        ContentType:             nonEmptyString(source.ContentType),
+        Expires:                 source.Expires,
+        Metadata:                aws.StringMap(source.Metadata),
+        RequestPayer:            nonEmptyString(options.RequestPayer),
+        StorageClass:            nonEmptyString(options.StorageClass),
+        Tagging:                 nonEmptyString(source.Tagging),
+        WebsiteRedirectLocation: nonEmptyString(source.WebsiteRedirect),
+        ServerSideEncryption:    options.Encryption.serverSideEncryption(),
+        SSEKMSKeyId:             options.Encryption.kmsKeyId(),
+        SSEKMSEncryptionContext: options.Encryption.kmsEncryptionContext(),
+        SSECustomerAlgorithm:    algorithm,
+        SSECustomerKey:          customerKey,
+    }
+
+    upload, err := b.svc.CreateMultipartUploadWithContext(ctx, input)
+
+    if err != nil {
+        return err
+    }
+
+    parts, err := b.copyParts(ctx, object, bucket, key, *upload.UploadId, options)
+
+    if err == nil {
+        _, err = b.svc.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+            Bucket:          aws.String(bucket),
+            Key:             aws.String(key),
+            UploadId:        upload.UploadId,
+            RequestPayer:    nonEmptyString(options.RequestPayer),
+            MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+        })
+    }
+
+    // Parts that were already copied are kept, and billed, until the upload is aborted.
+    if err != nil {
+        b.abortUpload(bucket, key, *upload.UploadId, options.RequestPayer)
+    }
+
+    return err
+}
+
+// Copies every part of the object into the upload, returning the parts in order. Stops at the first error.
+func(b *S3Backend) copyParts(ctx context.Context, object ObjectInfo, bucket, key, uploadId string, options *MoveObjectOptions) ([]*s3.CompletedPart, error) {
+    size := *object.Size
+    partSize := b.partSize(size)
+    count := int((size + partSize - 1) / partSize)
+
+    concurrency := b.options.MultipartConcurrency
+    if concurrency <= 0 {
+        concurrency = s3manager.DefaultUploadConcurrency
+    }
+
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    algorithm, customerKey := customerKeyParams(options.Encryption.CustomerKey)
+    sourceAlgorithm, sourceCustomerKey := customerKeyParams(options.Encryption.SourceCustomerKey)
+
+    partCh := make(chan int)
+
+    go func() {
+        defer close(partCh)
+
+        for i := 0; i < count; i++ {
+            select {
+                case partCh <- i:
+                case <-ctx.Done():
+                    return
+            }
+        }
+    }()
+
+    parts := make([]*s3.CompletedPart, count)
+
+    var wg sync.WaitGroup
+    var once sync.Once
+    var firstErr error
+
+    for w := 0; w < concurrency; w++ {
+        wg.Add(1)
+
+        go func() {
+            defer wg.Done()
+
+            for i := range partCh {
+                start := int64(i) * partSize
+                end := minInt64(start + partSize, size) - 1
+
+                output, err := b.svc.UploadPartCopyWithContext(ctx, &s3.UploadPartCopyInput{
+                    Bucket:                         aws.String(bucket),
+                    Key:                            aws.String(key),
+                    UploadId:                       aws.String(uploadId),
+                    PartNumber:                     aws.Int64(int64(i + 1)),
+                    CopySource:                     aws.String(copySource(object)),
+                    CopySourceRange:                aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+                    RequestPayer:                   nonEmptyString(options.RequestPayer),
+                    SSECustomerAlgorithm:           algorithm,
+                    SSECustomerKey:                 customerKey,
+                    CopySourceSSECustomerAlgorithm: sourceAlgorithm,
+                    CopySourceSSECustomerKey:       sourceCustomerKey,
+                })
+
+                if err != nil {
+                    once.Do(func() {
+                        firstErr = err
+                        cancel()
+                    })
+                    return
+                }
+
+                parts[i] = &s3.CompletedPart{ETag: output.CopyPartResult.ETag, PartNumber: aws.Int64(int64(i + 1))}
+            }
+        }()
+    }
+
+    wg.Wait()
+
+    return parts, firstErr
+}
+
+// The backend to read a copy source through. The source may have been listed with credentials of
+// its own.
+func(b *S3Backend) sourceBackend(object ObjectInfo) *S3Backend {
+    if backend, ok := object.backend.(*S3Backend); ok {
+        return backend
+    }
+    return b
+}
+
+// Reads what CopyObject would keep of the object: its metadata and content headers unless the
+// directive is REPLACE, and its tags and website redirect unless new ones are given.
+func(b *S3Backend) GetMetadata(ctx context.Context, object ObjectInfo, options *MoveObjectOptions) (*MoveObjectOptions, error) {
+    svc, err := b.serviceForBucket(*object.Bucket)
+
+    if err != nil {
+        return nil, err
+    }
+
+    algorithm, customerKey := customerKeyParams(options.Encryption.SourceCustomerKey)
+
+    source, err := svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+        Bucket:               object.Bucket,
+        Key:                  object.Key,
+        VersionId:            object.VersionId,
+        RequestPayer:         nonEmptyString(options.RequestPayer),
+        SSECustomerAlgorithm: algorithm,
+        SSECustomerKey:       customerKey,
+    })
+
+    if err != nil {
+        return nil, err
+    }
+
+    tagging, err := objectTagging(ctx, svc, object)
+
+    if err != nil {
+        return nil, err
+    }
+
+    merged := *options
+
+    if options.MetadataDirective != METADATA_DIRECTIVE_REPLACE {
+        merged.CacheControl = aws.StringValue(source.CacheControl)
+        merged.ContentDisposition = aws.StringValue(source.ContentDisposition)
+        merged.ContentEncoding = aws.StringValue(source.ContentEncoding)
+        merged.ContentLanguage = aws.StringValue(source.ContentLanguage)
+        merged.ContentType = aws.StringValue(source.ContentType)
+        merged.Expires = nil
+        merged.Metadata = make(map[string]string)
+
+        if source.Expires != nil {
+            if expires, err := http.ParseTime(*source.Expires); err == nil {
+                merged.Expires = &expires
+            }
+        }
+
+        for key, value := range source.Metadata {
+            merged.Metadata[strings.ToLower(key)] = aws.StringValue(value)
+        }
+    }
+
+    if options.Tagging == "" {
+        merged.Tagging = tagging
+    }
+
+    if options.WebsiteRedirect == "" {
+        merged.WebsiteRedirect = aws.StringValue(source.WebsiteRedirectLocation)
+    }
+
+    return &merged, nil
+}
+
+// The object's tags, encoded as a query string the way the x-amz-tagging header expects them.
+func objectTagging(ctx context.Context, svc *s3.S3, object ObjectInfo) (string, error) {
+    output, err := svc.GetObjectTaggingWithContext(ctx, &s3.GetObjectTaggingInput{
+        Bucket:    object.Bucket,
+        Key:       object.Key,
+        VersionId: object.VersionId,
+    })
+
+    // Not every S3 compatible system supports tags, in which case there aren't any to keep.
+    if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NotImplemented" {
+        return "", nil
+    }
+
+    if err != nil {
+        return "", err
+    }
+
+    values := url.Values{}
+
+    for _, tag := range output.TagSet {
+        values.Set(aws.StringValue(tag.Key), aws.StringValue(tag.Value))
+    }
+
+    return values.Encode(), nil
+}
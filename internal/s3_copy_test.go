@@ -0,0 +1,64 @@
+package s3go
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+    var tests = []struct {
+        value   string
+        want    map[string]string
+        wantErr bool
+    }{
+        {"", nil, false},
+        {"owner=data", map[string]string{"owner": "data"}, false},
+        {"owner=data, build=42", map[string]string{"owner": "data", "build": "42"}, false},
+        {"query=a=b", map[string]string{"query": "a=b"}, false},
+        {"owner", nil, true},
+        {"=data", nil, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.value, func(t *testing.T) {
+            got, err := ParseMetadata(tt.value)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("got %v, want error: %v", err, tt.wantErr)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("got %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestValidateMetadataDirective(t *testing.T) {
+    for value, wantErr := range map[string]bool{"": false, "COPY": false, "REPLACE": false, "replace": true} {
+        if err := ValidateMetadataDirective(value); (err != nil) != wantErr {
+            t.Errorf("%q: got %v, want error: %v", value, err, wantErr)
+        }
+    }
+}
+
+func TestCopySource(t *testing.T) {
+    bucket, version := "my-bucket", "3/L4kqtJl+cA=="
+
+    var tests = []struct {
+        key       string
+        versionId *string
+        want      string
+    }{
+        {"logs/1.log", nil, "my-bucket/logs/1.log"},
+        {"a?b#c%d+e f.txt", nil, "my-bucket/a%3Fb%23c%25d%2Be%20f.txt"},
+        {"dir/a?versionId=1", &version, "my-bucket/dir/a%3FversionId=1?versionId=3%2FL4kqtJl%2BcA%3D%3D"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.key, func(t *testing.T) {
+            key := tt.key
+            if got := copySource(ObjectInfo{Bucket: &bucket, Key: &key, VersionId: tt.versionId}); got != tt.want {
+                t.Errorf("got %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
@@ -1,18 +1,12 @@
 package s3go
 
 import (
-	"errors"
 	"fmt"
-	"net/http"
     "path/filepath"
 	"net/url"
 	"strings"
 )
 
-const (
-    DEFAULT_ENDPOINT_URL = "s3.amazonaws.com"
-)
-
 type S3Url struct {
 	// The proto scheme of the URL. Should usually be only S3 or blank.
 	Scheme  string
@@ -24,10 +18,26 @@ type S3Url struct {
 	Prefix  string
 }
 
+// Stands for stdin as a source, or stdout as a target.
+const STREAM_PATH = "-"
+
 func (u *S3Url) IsLocal() bool {
 	return u.Scheme != "s3"
 }
 
+// Whether the URL is "-", i.e. stdin or stdout rather than a local path.
+func (u *S3Url) IsStream() bool {
+	return u.IsLocal() && u.Prefix == STREAM_PATH
+}
+
+// The URL of an object with the given key under this URL's scheme and bucket.
+func (u *S3Url) ObjectUrl(key string) string {
+	if u.Scheme == "" {
+		return key
+	}
+	return fmt.Sprintf("%s://%s/%s", u.Scheme, u.Bucket, key)
+}
+
 func ParseUrl(input string) (*S3Url, error) {
 	u, err := url.Parse(input)
 
@@ -60,31 +70,26 @@ func ParseUrl(input string) (*S3Url, error) {
 	return output, nil
 }
 
-func GetBucketRegion(bucket string) (string, error) {
-    url := fmt.Sprintf("https://%s.%s", bucket, DEFAULT_ENDPOINT_URL)
-    res, err := http.Head(url)
-    if err != nil {
-        return "", err
-    }
-    if res.StatusCode == 404 {
-        return "", errors.New("(NoSuchBucket) Provided bucket does not exist")
-    }
-    return res.Header.Get("X-Amz-Bucket-Region"), nil
-}
-
 func buildTargetPrefix(targetPrefix, sourcePrefix, objectKey string, recursive bool) string {
     dir, fname := filepath.Split(objectKey)
 
-    if targetPrefix == "" {
-    	return fname
-    }
-
     if recursive {
 	    substr := IntMin(len(sourcePrefix), len(dir))
-        targetPrefix = strings.TrimRight(targetPrefix, "/") + "/" + dir[substr:]
+        relative := strings.TrimLeft(dir[substr:], "/") + fname
+
+        // Recursive transfers into the root of a bucket keep their directory structure.
+        if targetPrefix == "" {
+            return relative
+        }
+
+        return strings.TrimRight(targetPrefix, "/") + "/" + relative
+    }
+
+    if targetPrefix == "" {
+    	return fname
     }
 
-    if recursive || (len(targetPrefix) > 0 && targetPrefix[len(targetPrefix)-1:] == "/") {
+    if targetPrefix[len(targetPrefix)-1:] == "/" {
         targetPrefix = targetPrefix + fname
     }
 
@@ -1,6 +1,7 @@
 package s3go
 
 import (
+    "fmt"
     "testing"
 )
 
@@ -54,10 +55,28 @@ func TestParseUrl(t *testing.T) {
     }
 }
 
-func TestGetBucketRegion(t *testing.T) {
-
-}
-
 func TestBuildTargetPrefix(t *testing.T) {
+    var tests = []struct {
+        target, source, key string
+        recursive bool
+        want string
+    }{
+        {"", "", "data/file.json", false, "file.json"},
+        {"backup/", "", "data/file.json", false, "backup/file.json"},
+        {"backup/renamed.json", "", "data/file.json", false, "backup/renamed.json"},
+        {"backup", "data/", "data/a/file.json", true, "backup/a/file.json"},
+        {"backup/", "data/", "data/file.json", true, "backup/file.json"},
+        {"", "data/", "data/a/file.json", true, "a/file.json"},
+        {"backup", "data", "data/a/file.json", true, "backup/a/file.json"},
+    }
 
+    for _, tt := range tests {
+        testname := fmt.Sprintf("%s,%s,%s,%v", tt.target, tt.source, tt.key, tt.recursive)
+        t.Run(testname, func(t *testing.T) {
+            ans := buildTargetPrefix(tt.target, tt.source, tt.key, tt.recursive)
+            if ans != tt.want {
+                t.Errorf("got %s, want %s", ans, tt.want)
+            }
+        })
+    }
 }
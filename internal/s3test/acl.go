@@ -0,0 +1,120 @@
+package s3test
+
+import (
+    "encoding/xml"
+    "net/http"
+)
+
+// The canonical user id of whoever owns every bucket and object.
+const OwnerId = "s3test-owner"
+
+const (
+    allUsersGroup           = "http://acs.amazonaws.com/groups/global/AllUsers"
+    authenticatedUsersGroup = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+)
+
+// A permission given to a canonical user id or a group URI.
+type Grant struct {
+    Grantee string
+
+    Permission string
+}
+
+// The grants on the object: those set with PutObjectAcl, or else the ones its canned ACL stands for.
+func (o *Object) Grants() []Grant {
+    if o.grants != nil {
+        return o.grants
+    }
+
+    grants := []Grant{{OwnerId, "FULL_CONTROL"}}
+
+    switch o.Header.Get("X-Amz-Acl") {
+    case "public-read":
+        grants = append(grants, Grant{allUsersGroup, "READ"})
+    case "public-read-write":
+        grants = append(grants, Grant{allUsersGroup, "READ"}, Grant{allUsersGroup, "WRITE"})
+    case "authenticated-read":
+        grants = append(grants, Grant{authenticatedUsersGroup, "READ"})
+    }
+
+    return grants
+}
+
+type aclGrantee struct {
+    XMLNS string `xml:"xmlns:xsi,attr"`
+    Type  string `xml:"xsi:type,attr"`
+    ID    string `xml:",omitempty"`
+    URI   string `xml:",omitempty"`
+}
+
+type aclGrant struct {
+    Grantee    aclGrantee
+    Permission string
+}
+
+type accessControlPolicy struct {
+    XMLName xml.Name   `xml:"AccessControlPolicy"`
+    OwnerId string     `xml:"Owner>ID"`
+    Grants  []aclGrant `xml:"AccessControlList>Grant"`
+}
+
+func (s *Server) getObjectAcl(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+    object, ok := b.version(key, r.URL.Query().Get("versionId"))
+
+    if !ok || object.DeleteMarker {
+        writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+        return
+    }
+
+    result := accessControlPolicy{OwnerId: OwnerId}
+
+    for _, grant := range object.Grants() {
+        grantee := aclGrantee{XMLNS: "http://www.w3.org/2001/XMLSchema-instance", Type: "CanonicalUser", ID: grant.Grantee}
+
+        if grant.Grantee != OwnerId {
+            grantee = aclGrantee{XMLNS: grantee.XMLNS, Type: "Group", URI: grant.Grantee}
+        }
+
+        result.Grants = append(result.Grants, aclGrant{grantee, grant.Permission})
+    }
+
+    writeXML(w, http.StatusOK, result)
+}
+
+// Replaces the object's ACL with a canned ACL from the x-amz-acl header, or the grants in the body.
+func (s *Server) putObjectAcl(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+    object, ok := b.version(key, r.URL.Query().Get("versionId"))
+
+    if !ok || object.DeleteMarker {
+        writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+        return
+    }
+
+    if canned := r.Header.Get("X-Amz-Acl"); canned != "" {
+        object.Header.Set("X-Amz-Acl", canned)
+        object.grants = nil
+        w.WriteHeader(http.StatusOK)
+        return
+    }
+
+    var policy accessControlPolicy
+
+    if err := readXML(r, &policy); err != nil {
+        writeError(w, http.StatusBadRequest, "MalformedACLError", err.Error())
+        return
+    }
+
+    object.Header.Del("X-Amz-Acl")
+    object.grants = []Grant{}
+
+    for _, grant := range policy.Grants {
+        grantee := grant.Grantee.ID
+        if grantee == "" {
+            grantee = grant.Grantee.URI
+        }
+
+        object.grants = append(object.grants, Grant{grantee, grant.Permission})
+    }
+
+    w.WriteHeader(http.StatusOK)
+}
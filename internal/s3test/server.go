@@ -0,0 +1,1399 @@
+// Package s3test provides an in-process, in-memory S3 API server for end-to-end tests.
+// It understands enough of the API for every s3go command, and accepts any credentials.
+package s3test
+
+import (
+    "crypto/md5"
+    "encoding/hex"
+    "encoding/xml"
+    "fmt"
+    "io/ioutil"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Request headers that are stored with an object and returned by GetObject and HeadObject.
+var persistedHeaders = []string{
+    "Cache-Control",
+    "Content-Disposition",
+    "Content-Encoding",
+    "Content-Language",
+    "Content-Type",
+    "Expires",
+    "X-Amz-Acl",
+    "X-Amz-Storage-Class",
+    "X-Amz-Tagging",
+    "X-Amz-Website-Redirect-Location",
+    "X-Amz-Server-Side-Encryption",
+    "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id",
+    "X-Amz-Server-Side-Encryption-Context",
+    "X-Amz-Server-Side-Encryption-Customer-Algorithm",
+    "X-Amz-Server-Side-Encryption-Customer-Key-Md5",
+}
+
+// Headers describing how an object is encrypted. Copies never inherit them from the source.
+var encryptionHeaders = []string{
+    "X-Amz-Server-Side-Encryption",
+    "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id",
+    "X-Amz-Server-Side-Encryption-Context",
+    "X-Amz-Server-Side-Encryption-Customer-Algorithm",
+    "X-Amz-Server-Side-Encryption-Customer-Key-Md5",
+}
+
+type Object struct {
+    // Contents of the object
+    Body []byte
+
+    // Hex encoded MD5 of the body, or the multipart ETag if it was uploaded in parts
+    ETag string
+
+    LastModified time.Time
+
+    // Stored headers, including Content-* and x-amz-meta-* headers
+    Header http.Header
+
+    // Value of the x-amz-restore header, set once RestoreObject has been called
+    Restore string
+
+    // Days and tier of the last RestoreObject request
+    RestoreDays int
+
+    RestoreTier string
+
+    restoreRequestedAt time.Time
+
+    // "null" unless the object was written while versioning was enabled
+    VersionId string
+
+    // Whether this version is a delete marker rather than an object
+    DeleteMarker bool
+
+    // Grants set with PutObjectAcl. Nil means the canned ACL in the x-amz-acl header applies.
+    grants []Grant
+}
+
+type upload struct {
+    key string
+
+    header http.Header
+
+    parts map[int][]byte
+}
+
+type bucket struct {
+    // The current version of every key
+    objects map[string]*Object
+
+    // Every version of every key, including delete markers, oldest first
+    versions map[string][]*Object
+
+    uploads map[string]*upload
+
+    // Versioning status: "", "Enabled" or "Suspended"
+    versioning string
+
+    nextVersionId int
+}
+
+// An httptest.Server backed by an in-memory S3 implementation.
+type Server struct {
+    *httptest.Server
+
+    // The region reported for every bucket
+    Region string
+
+    // How long restores of archived objects take. Zero means they never finish on their own.
+    RestoreDuration time.Duration
+
+    // Largest source a single CopyObject accepts. Zero means S3's limit of 5 GiB.
+    MaxCopySize int64
+
+    mu sync.Mutex
+
+    buckets map[string]*bucket
+
+    // Object operations on these keys fail with AccessDenied, keyed by bucket and key
+    denied map[string]bool
+
+    // The only access key allowed to use each of these buckets, including as a copy source
+    owners map[string]string
+
+    // Listings of these buckets fail with AccessDenied
+    unlistable map[string]bool
+
+    // Number of DeleteObjects requests served
+    batchDeletes int
+
+    nextUploadId int
+}
+
+// Starts a new server. Callers should Close it when they are done.
+func NewServer() *Server {
+    s := &Server{
+        Region:  "us-east-1",
+        buckets: make(map[string]*bucket),
+        denied:  make(map[string]bool),
+        owners:  make(map[string]string),
+
+        unlistable: make(map[string]bool),
+    }
+    s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+    return s
+}
+
+// A RoundTripper that sends every request to this server, whatever host it was addressed to.
+// The original host is kept in the Host header so virtual-hosted bucket names still resolve.
+func (s *Server) Transport() http.RoundTripper {
+    return &redirectTransport{s.Listener.Addr().String(), &http.Transport{}}
+}
+
+type redirectTransport struct {
+    addr string
+
+    transport *http.Transport
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    clone := req.WithContext(req.Context())
+    clone.URL = &url.URL{}
+    *clone.URL = *req.URL
+
+    if clone.Host == "" {
+        clone.Host = req.URL.Host
+    }
+
+    clone.URL.Scheme = "http"
+    clone.URL.Host = t.addr
+
+    return t.transport.RoundTrip(clone)
+}
+
+// Creates a bucket directly, without going through the API.
+func (s *Server) CreateBucket(name string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if _, ok := s.buckets[name]; !ok {
+        s.buckets[name] = newBucket()
+    }
+}
+
+// Indicates whether the bucket exists.
+func (s *Server) HasBucket(name string) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    _, ok := s.buckets[name]
+    return ok
+}
+
+// Stores an object directly, creating the bucket if needed.
+func (s *Server) PutObject(bucketName, key string, body []byte) *Object {
+    s.CreateBucket(bucketName)
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    object := newObject(body, http.Header{})
+    s.buckets[bucketName].put(key, object)
+    return object
+}
+
+// Returns the object stored under the key, or nil if there isn't one.
+func (s *Server) GetObject(bucketName, key string) *Object {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if b, ok := s.buckets[bucketName]; ok {
+        return b.objects[key]
+    }
+    return nil
+}
+
+// Sorted keys of every object in the bucket.
+func (s *Server) Keys(bucketName string) []string {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    keys := []string{}
+
+    if b, ok := s.buckets[bucketName]; ok {
+        for key := range b.objects {
+            keys = append(keys, key)
+        }
+    }
+
+    sort.Strings(keys)
+    return keys
+}
+
+func newBucket() *bucket {
+    return &bucket{
+        objects:  make(map[string]*Object),
+        versions: make(map[string][]*Object),
+        uploads:  make(map[string]*upload),
+    }
+}
+
+func newObject(body []byte, header http.Header) *Object {
+    sum := md5.Sum(body)
+
+    if header.Get("Content-Type") == "" {
+        header.Set("Content-Type", "binary/octet-stream")
+    }
+
+    return &Object{
+        Body:         body,
+        ETag:         hex.EncodeToString(sum[:]),
+        LastModified: time.Now().UTC().Truncate(time.Second),
+        Header:       header,
+    }
+}
+
+// Copies the headers that S3 would store with an object out of a request.
+func storedHeaders(h http.Header) http.Header {
+    header := http.Header{}
+
+    for _, name := range persistedHeaders {
+        if v := h.Get(name); v != "" {
+            header.Set(name, v)
+        }
+    }
+
+    for name, values := range h {
+        if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+            header[name] = values
+        }
+    }
+
+    return header
+}
+
+// The number of DeleteObjects requests served so far.
+func (s *Server) BatchDeletes() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    return s.batchDeletes
+}
+
+// Makes every object operation on the key fail with AccessDenied, to simulate partial failures.
+func (s *Server) DenyKey(bucketName, key string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.denied[bucketName+"/"+key] = true
+}
+
+// Makes listings of the bucket fail with AccessDenied, as if s3:ListBucket wasn't granted.
+func (s *Server) DenyListing(bucketName string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.unlistable[bucketName] = true
+}
+
+// Makes every request for the bucket that isn't signed with accessKeyId fail with AccessDenied,
+// as if the bucket belonged to another account.
+func (s *Server) SetBucketOwner(bucketName, accessKeyId string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.owners[bucketName] = accessKeyId
+}
+
+// Whether the request may use the bucket, and the bucket it copies from if there is one.
+func (s *Server) isAllowed(r *http.Request, bucketName string) bool {
+    buckets := []string{bucketName}
+
+    if source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source")); err == nil && source != "" {
+        buckets = append(buckets, strings.SplitN(strings.TrimPrefix(source, "/"), "/", 2)[0])
+    }
+
+    for _, name := range buckets {
+        if owner, ok := s.owners[name]; ok && owner != accessKeyId(r) {
+            return false
+        }
+    }
+
+    return true
+}
+
+// The access key a request was signed with, for both v4 and v2 signatures.
+func accessKeyId(r *http.Request) string {
+    auth := r.Header.Get("Authorization")
+
+    if i := strings.Index(auth, "Credential="); i >= 0 {
+        return strings.SplitN(auth[i+len("Credential="):], "/", 2)[0]
+    }
+
+    if strings.HasPrefix(auth, "AWS ") {
+        return strings.SplitN(strings.TrimPrefix(auth, "AWS "), ":", 2)[0]
+    }
+
+    return strings.SplitN(r.URL.Query().Get("X-Amz-Credential"), "/", 2)[0]
+}
+
+// Splits a request into bucket and key, supporting both path-style and virtual-hosted addressing.
+func (s *Server) parseRequest(r *http.Request) (string, string) {
+    path := strings.TrimPrefix(r.URL.Path, "/")
+
+    if bucketName := bucketFromHost(r.Host); bucketName != "" {
+        return bucketName, path
+    }
+
+    parts := strings.SplitN(path, "/", 2)
+
+    if len(parts) == 1 {
+        return parts[0], ""
+    }
+
+    return parts[0], parts[1]
+}
+
+func bucketFromHost(host string) string {
+    if h, _, err := net.SplitHostPort(host); err == nil {
+        host = h
+    }
+
+    if i := strings.Index(host, ".s3"); i > 0 {
+        return host[:i]
+    }
+
+    if i := strings.Index(host, "."); i > 0 {
+        rest := host[i+1:]
+
+        if rest == "localhost" || net.ParseIP(rest) != nil {
+            return host[:i]
+        }
+    }
+
+    return ""
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    bucketName, key := s.parseRequest(r)
+    query := r.URL.Query()
+
+    if bucketName == "" {
+        s.listBuckets(w, r)
+        return
+    }
+
+    if !s.isAllowed(r, bucketName) {
+        writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied")
+        return
+    }
+
+    if key == "" {
+        switch {
+        case r.Method == "PUT":
+            s.createBucket(w, bucketName)
+        case r.Method == "HEAD":
+            s.headBucket(w, bucketName)
+        case r.Method == "DELETE":
+            s.deleteBucket(w, bucketName)
+        case r.Method == "GET" && has(query, "location"):
+            s.getBucketLocation(w, bucketName)
+        case r.Method == "GET" && has(query, "uploads"):
+            s.listMultipartUploads(w, bucketName)
+        case has(query, "versioning") || has(query, "versions"):
+            s.handleVersions(w, r, bucketName)
+        case r.Method == "GET":
+            s.listObjects(w, r, bucketName)
+        case r.Method == "POST" && has(query, "delete"):
+            s.deleteObjects(w, r, bucketName)
+        default:
+            writeError(w, http.StatusNotImplemented, "NotImplemented", "The requested bucket operation is not implemented.")
+        }
+        return
+    }
+
+    b, ok := s.buckets[bucketName]
+
+    if !ok {
+        writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+        return
+    }
+
+    if s.denied[bucketName+"/"+key] {
+        writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied")
+        return
+    }
+
+    switch {
+    case r.Method == "POST" && has(query, "uploads"):
+        s.createMultipartUpload(w, r, b, bucketName, key)
+    case r.Method == "PUT" && has(query, "uploadId"):
+        s.uploadPart(w, r, b)
+    case r.Method == "POST" && has(query, "uploadId"):
+        s.completeMultipartUpload(w, r, b, bucketName, key)
+    case r.Method == "DELETE" && has(query, "uploadId"):
+        s.abortMultipartUpload(w, r, b)
+    case r.Method == "POST" && has(query, "restore"):
+        s.restoreObject(w, r, b, key)
+    case r.Method == "GET" && has(query, "tagging"):
+        s.getObjectTagging(w, r, b, key)
+    case r.Method == "GET" && has(query, "acl"):
+        s.getObjectAcl(w, r, b, key)
+    case r.Method == "PUT" && has(query, "acl"):
+        s.putObjectAcl(w, r, b, key)
+    case r.Method == "PUT" && r.Header.Get("X-Amz-Copy-Source") != "":
+        s.copyObject(w, r, b, key)
+    case r.Method == "PUT":
+        s.putObject(w, r, b, key)
+    case r.Method == "GET" || r.Method == "HEAD":
+        s.getObject(w, r, b, key)
+    case r.Method == "DELETE":
+        s.deleteObject(w, r, b, key)
+    default:
+        writeError(w, http.StatusNotImplemented, "NotImplemented", "The requested object operation is not implemented.")
+    }
+}
+
+func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request, bucketName string) {
+    b, ok := s.buckets[bucketName]
+
+    if !ok {
+        writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+        return
+    }
+
+    query := r.URL.Query()
+
+    switch {
+    case r.Method == "GET" && has(query, "versions"):
+        s.listObjectVersions(w, r, bucketName, b)
+    case r.Method == "GET":
+        s.getBucketVersioning(w, b)
+    case r.Method == "PUT":
+        s.putBucketVersioning(w, r, b)
+    default:
+        writeError(w, http.StatusNotImplemented, "NotImplemented", "The requested bucket operation is not implemented.")
+    }
+}
+
+func has(query url.Values, name string) bool {
+    _, ok := query[name]
+    return ok
+}
+
+type errorResponse struct {
+    XMLName xml.Name `xml:"Error"`
+    Code    string
+    Message string
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+    writeXML(w, status, errorResponse{Code: code, Message: message})
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+    body, err := xml.Marshal(v)
+
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/xml")
+    w.WriteHeader(status)
+    w.Write([]byte(xml.Header))
+    w.Write(body)
+}
+
+type bucketEntry struct {
+    Name         string
+    CreationDate string
+}
+
+type listBucketsResult struct {
+    XMLName xml.Name      `xml:"ListAllMyBucketsResult"`
+    Buckets []bucketEntry `xml:"Buckets>Bucket"`
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+    result := listBucketsResult{}
+
+    for name := range s.buckets {
+        result.Buckets = append(result.Buckets, bucketEntry{name, formatTime(time.Now())})
+    }
+
+    sort.Slice(result.Buckets, func(i, j int) bool { return result.Buckets[i].Name < result.Buckets[j].Name })
+    writeXML(w, http.StatusOK, result)
+}
+
+func (s *Server) createBucket(w http.ResponseWriter, bucketName string) {
+    if _, ok := s.buckets[bucketName]; ok {
+        writeError(w, http.StatusConflict, "BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded and you already own it.")
+        return
+    }
+
+    s.buckets[bucketName] = newBucket()
+    w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) headBucket(w http.ResponseWriter, bucketName string) {
+    w.Header().Set("X-Amz-Bucket-Region", s.Region)
+
+    if _, ok := s.buckets[bucketName]; !ok {
+        w.WriteHeader(http.StatusNotFound)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) deleteBucket(w http.ResponseWriter, bucketName string) {
+    b, ok := s.buckets[bucketName]
+
+    if !ok {
+        writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+        return
+    }
+
+    if !b.isEmpty() || len(b.uploads) > 0 {
+        writeError(w, http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty.")
+        return
+    }
+
+    delete(s.buckets, bucketName)
+    w.WriteHeader(http.StatusNoContent)
+}
+
+type locationConstraint struct {
+    XMLName xml.Name `xml:"LocationConstraint"`
+    Value   string   `xml:",chardata"`
+}
+
+func (s *Server) getBucketLocation(w http.ResponseWriter, bucketName string) {
+    if _, ok := s.buckets[bucketName]; !ok {
+        writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+        return
+    }
+
+    location := s.Region
+    if location == "us-east-1" {
+        location = ""
+    }
+
+    writeXML(w, http.StatusOK, locationConstraint{Value: location})
+}
+
+type contentsEntry struct {
+    Key          string
+    LastModified string
+    ETag         string
+    Size         int64
+    StorageClass string
+}
+
+type prefixEntry struct {
+    Prefix string
+}
+
+type listBucketResult struct {
+    XMLName               xml.Name `xml:"ListBucketResult"`
+    Name                  string
+    Prefix                string
+    Delimiter             string `xml:",omitempty"`
+    Marker                string `xml:",omitempty"`
+    NextMarker            string `xml:",omitempty"`
+    ContinuationToken     string `xml:",omitempty"`
+    NextContinuationToken string `xml:",omitempty"`
+    KeyCount              int
+    MaxKeys               int
+    IsTruncated           bool
+    Contents              []contentsEntry
+    CommonPrefixes        []prefixEntry
+}
+
+// Handles both ListObjects and ListObjectsV2, depending on the list-type parameter.
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, bucketName string) {
+    b, ok := s.buckets[bucketName]
+
+    if !ok {
+        writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+        return
+    }
+
+    if s.unlistable[bucketName] {
+        writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied")
+        return
+    }
+
+    query := r.URL.Query()
+    prefix := query.Get("prefix")
+    delimiter := query.Get("delimiter")
+    isV2 := query.Get("list-type") == "2"
+
+    maxKeys := 1000
+    if v, err := strconv.Atoi(query.Get("max-keys")); err == nil && v > 0 {
+        maxKeys = v
+    }
+
+    after := query.Get("marker")
+    if isV2 {
+        after = query.Get("continuation-token")
+        if after == "" {
+            after = query.Get("start-after")
+        }
+    }
+
+    keys := make([]string, 0, len(b.objects))
+    for key := range b.objects {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    result := listBucketResult{Name: bucketName, Prefix: prefix, Delimiter: delimiter, MaxKeys: maxKeys}
+    seenPrefixes := make(map[string]bool)
+    last := ""
+
+    for _, key := range keys {
+        if !strings.HasPrefix(key, prefix) || key <= after {
+            continue
+        }
+
+        if result.KeyCount >= maxKeys {
+            result.IsTruncated = true
+            break
+        }
+
+        if delimiter != "" {
+            if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+                commonPrefix := key[:len(prefix)+i+len(delimiter)]
+
+                if !seenPrefixes[commonPrefix] {
+                    seenPrefixes[commonPrefix] = true
+                    result.CommonPrefixes = append(result.CommonPrefixes, prefixEntry{commonPrefix})
+                    result.KeyCount++
+                }
+
+                last = key
+                continue
+            }
+        }
+
+        object := b.objects[key]
+
+        result.Contents = append(result.Contents, contentsEntry{
+            Key:          key,
+            LastModified: formatTime(object.LastModified),
+            ETag:         `"` + object.ETag + `"`,
+            Size:         int64(len(object.Body)),
+            StorageClass: storageClass(object),
+        })
+        result.KeyCount++
+        last = key
+    }
+
+    if result.IsTruncated {
+        if isV2 {
+            result.ContinuationToken = query.Get("continuation-token")
+            result.NextContinuationToken = last
+        } else {
+            result.NextMarker = last
+        }
+    }
+
+    writeXML(w, http.StatusOK, result)
+}
+
+type deleteRequest struct {
+    Quiet   bool
+    Objects []struct {
+        Key       string
+        VersionId string
+    } `xml:"Object"`
+}
+
+type deletedEntry struct {
+    Key       string
+    VersionId string `xml:",omitempty"`
+}
+
+type deleteError struct {
+    Key       string
+    VersionId string `xml:",omitempty"`
+    Code      string
+    Message string
+}
+
+type deleteResult struct {
+    XMLName xml.Name       `xml:"DeleteResult"`
+    Deleted []deletedEntry `xml:"Deleted"`
+    Errors  []deleteError  `xml:"Error"`
+}
+
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request, bucketName string) {
+    b, ok := s.buckets[bucketName]
+
+    if !ok {
+        writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+        return
+    }
+
+    request := deleteRequest{}
+
+    if err := readXML(r, &request); err != nil {
+        writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+        return
+    }
+
+    if len(request.Objects) > 1000 {
+        writeError(w, http.StatusBadRequest, "MalformedXML", "A delete request can contain at most 1000 keys.")
+        return
+    }
+
+    s.batchDeletes++
+    result := deleteResult{}
+
+    for _, object := range request.Objects {
+        if s.denied[bucketName+"/"+object.Key] {
+            result.Errors = append(result.Errors, deleteError{object.Key, object.VersionId, "AccessDenied", "Access Denied"})
+            continue
+        }
+
+        if object.VersionId != "" {
+            b.removeVersion(object.Key, object.VersionId)
+        } else {
+            b.remove(object.Key)
+        }
+
+        if !request.Quiet {
+            result.Deleted = append(result.Deleted, deletedEntry{object.Key, object.VersionId})
+        }
+    }
+
+    writeXML(w, http.StatusOK, result)
+}
+
+func readXML(r *http.Request, v interface{}) error {
+    body, err := ioutil.ReadAll(r.Body)
+
+    if err != nil {
+        return err
+    }
+
+    return xml.Unmarshal(body, v)
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+    body, err := ioutil.ReadAll(r.Body)
+
+    if err != nil {
+        writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+        return
+    }
+
+    object := newObject(body, storedHeaders(r.Header))
+    setVersionHeader(w, b.put(key, object))
+
+    w.Header().Set("ETag", `"`+object.ETag+`"`)
+    w.WriteHeader(http.StatusOK)
+}
+
+type copyObjectResult struct {
+    XMLName      xml.Name `xml:"CopyObjectResult"`
+    LastModified string
+    ETag         string
+}
+
+// Resolves the x-amz-copy-source header of a request to an object on this server.
+func (s *Server) copySource(r *http.Request) (*Object, error) {
+    source := r.Header.Get("X-Amz-Copy-Source")
+    versionId := ""
+
+    // The version is split off first, since an escaped "?" may be part of the key.
+    if i := strings.Index(source, "?"); i >= 0 {
+        if query, err := url.ParseQuery(source[i+1:]); err == nil {
+            versionId = query.Get("versionId")
+        }
+        source = source[:i]
+    }
+
+    source, err := url.PathUnescape(source)
+
+    if err != nil {
+        return nil, err
+    }
+
+    parts := strings.SplitN(strings.TrimPrefix(source, "/"), "/", 2)
+
+    if len(parts) != 2 {
+        return nil, fmt.Errorf("invalid copy source %s", source)
+    }
+
+    b, ok := s.buckets[parts[0]]
+
+    if !ok {
+        return nil, fmt.Errorf("no such bucket %s", parts[0])
+    }
+
+    object, ok := b.version(parts[1], versionId)
+
+    if !ok || object.DeleteMarker {
+        return nil, fmt.Errorf("no such key %s", parts[1])
+    }
+
+    return object, nil
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+    source, err := s.copySource(r)
+
+    if err != nil {
+        writeError(w, http.StatusNotFound, "NoSuchKey", err.Error())
+        return
+    }
+
+    if !s.isReadable(source) {
+        writeError(w, http.StatusForbidden, "InvalidObjectState", "The source object of the COPY action is not in the active tier and is only stored in Amazon Glacier.")
+        return
+    }
+
+    if !hasCustomerKey(source, r.Header.Get("X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key-Md5")) {
+        writeError(w, http.StatusBadRequest, "InvalidRequest", "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.")
+        return
+    }
+
+    maxCopySize := s.MaxCopySize
+    if maxCopySize == 0 {
+        maxCopySize = 5 * 1024 * 1024 * 1024
+    }
+
+    if int64(len(source.Body)) > maxCopySize {
+        writeError(w, http.StatusBadRequest, "InvalidRequest", fmt.Sprintf("The specified copy source is larger than the maximum allowable size for a copy source: %d", maxCopySize))
+        return
+    }
+
+    header := http.Header{}
+
+    // Without REPLACE, S3 keeps the metadata and content headers of the source object.
+    if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+        header = storedHeaders(r.Header)
+    } else {
+        for name, values := range source.Header {
+            header[name] = values
+        }
+
+        for _, name := range append([]string{"X-Amz-Acl", "X-Amz-Storage-Class"}, encryptionHeaders...) {
+            header.Del(name)
+            if v := r.Header.Get(name); v != "" {
+                header.Set(name, v)
+            }
+        }
+
+        if v := r.Header.Get("X-Amz-Website-Redirect-Location"); v != "" {
+            header.Set("X-Amz-Website-Redirect-Location", v)
+        }
+    }
+
+    // Tags have a directive of their own, and are copied unless it says REPLACE.
+    header.Del("X-Amz-Tagging")
+
+    if r.Header.Get("X-Amz-Tagging-Directive") == "REPLACE" {
+        if v := r.Header.Get("X-Amz-Tagging"); v != "" {
+            header.Set("X-Amz-Tagging", v)
+        }
+    } else if v := source.Header.Get("X-Amz-Tagging"); v != "" {
+        header.Set("X-Amz-Tagging", v)
+    }
+
+    body := make([]byte, len(source.Body))
+    copy(body, source.Body)
+
+    object := newObject(body, header)
+    object.ETag = source.ETag
+    setVersionHeader(w, b.put(key, object))
+
+    if source.VersionId != nullVersionId {
+        w.Header().Set("X-Amz-Copy-Source-Version-Id", source.VersionId)
+    }
+
+    writeXML(w, http.StatusOK, copyObjectResult{LastModified: formatTime(object.LastModified), ETag: `"` + object.ETag + `"`})
+}
+
+// Whether a request carries the customer key an SSE-C object was written with, identified by its MD5.
+// Objects that aren't encrypted with SSE-C can't be read with a key either.
+func hasCustomerKey(object *Object, keyMD5 string) bool {
+    return object.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5") == keyMD5
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+    object, ok := b.version(key, r.URL.Query().Get("versionId"))
+
+    if ok && object.DeleteMarker {
+        w.Header().Set("X-Amz-Delete-Marker", "true")
+        w.Header().Set("X-Amz-Version-Id", object.VersionId)
+        writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+        return
+    }
+
+    if !ok {
+        if r.Method == "HEAD" {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+        return
+    }
+
+    if !s.isReadable(object) && r.Method == "GET" {
+        writeError(w, http.StatusForbidden, "InvalidObjectState", "The operation is not valid for the object's storage class")
+        return
+    }
+
+    if !hasCustomerKey(object, r.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5")) {
+        if r.Method == "HEAD" {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        writeError(w, http.StatusBadRequest, "InvalidRequest", "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.")
+        return
+    }
+
+    if match := r.Header.Get("If-Match"); match != "" && strings.Trim(match, `"`) != object.ETag {
+        writeError(w, http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+        return
+    }
+
+    for name, values := range object.Header {
+        w.Header()[name] = values
+    }
+
+    if object.Restore != "" {
+        w.Header().Set("X-Amz-Restore", object.Restore)
+    }
+
+    setVersionHeader(w, object.VersionId)
+    w.Header().Set("ETag", `"`+object.ETag+`"`)
+    w.Header().Set("Last-Modified", object.LastModified.Format(http.TimeFormat))
+    w.Header().Set("Accept-Ranges", "bytes")
+
+    body := object.Body
+    status := http.StatusOK
+    size := int64(len(body))
+
+    if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+        start, end, err := parseRange(rangeHeader, size)
+
+        if err != nil {
+            writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", err.Error())
+            return
+        }
+
+        body = body[start : end+1]
+        status = http.StatusPartialContent
+        w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+    }
+
+    w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+    w.WriteHeader(status)
+
+    if r.Method != "HEAD" {
+        w.Write(body)
+    }
+}
+
+type tag struct {
+    Key   string
+    Value string
+}
+
+type tagging struct {
+    XMLName xml.Name `xml:"Tagging"`
+    TagSet  []tag    `xml:"TagSet>Tag"`
+}
+
+// Returns the tags that were stored with the object's x-amz-tagging header.
+func (s *Server) getObjectTagging(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+    object, ok := b.version(key, r.URL.Query().Get("versionId"))
+
+    if !ok || object.DeleteMarker {
+        writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+        return
+    }
+
+    values, err := url.ParseQuery(object.Header.Get("X-Amz-Tagging"))
+
+    if err != nil {
+        writeError(w, http.StatusInternalServerError, "InternalError", err.Error())
+        return
+    }
+
+    result := tagging{TagSet: []tag{}}
+
+    for name := range values {
+        result.TagSet = append(result.TagSet, tag{name, values.Get(name)})
+    }
+
+    sort.Slice(result.TagSet, func(i, j int) bool { return result.TagSet[i].Key < result.TagSet[j].Key })
+
+    writeXML(w, http.StatusOK, result)
+}
+
+// Parses a "bytes=start-end" header. Suffix ranges ("bytes=-500") are supported too.
+func parseRange(header string, size int64) (int64, int64, error) {
+    spec := strings.TrimPrefix(header, "bytes=")
+    parts := strings.SplitN(spec, "-", 2)
+
+    if len(parts) != 2 {
+        return 0, 0, fmt.Errorf("invalid range %s", header)
+    }
+
+    if parts[0] == "" {
+        n, err := strconv.ParseInt(parts[1], 10, 64)
+        if err != nil || n <= 0 {
+            return 0, 0, fmt.Errorf("invalid range %s", header)
+        }
+        if n > size {
+            n = size
+        }
+        return size - n, size - 1, nil
+    }
+
+    start, err := strconv.ParseInt(parts[0], 10, 64)
+    if err != nil || start >= size {
+        return 0, 0, fmt.Errorf("invalid range %s", header)
+    }
+
+    end := size - 1
+    if parts[1] != "" {
+        end, err = strconv.ParseInt(parts[1], 10, 64)
+        if err != nil || end < start {
+            return 0, 0, fmt.Errorf("invalid range %s", header)
+        }
+        if end >= size {
+            end = size - 1
+        }
+    }
+
+    return start, end, nil
+}
+
+type restoreRequest struct {
+    Days                 int
+    GlacierJobParameters struct {
+        Tier string
+    }
+}
+
+func (s *Server) restoreObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+    object, ok := b.version(key, r.URL.Query().Get("versionId"))
+
+    if !ok {
+        writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+        return
+    }
+
+    if class := storageClass(object); class != "GLACIER" && class != "DEEP_ARCHIVE" {
+        writeError(w, http.StatusForbidden, "InvalidObjectState", "Restore is not allowed for the object's current storage class")
+        return
+    }
+
+    request := restoreRequest{}
+
+    if err := readXML(r, &request); err != nil {
+        writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+        return
+    }
+
+    if object.Restore == `ongoing-request="true"` {
+        writeError(w, http.StatusConflict, "RestoreAlreadyInProgress", "Object restore is already in progress")
+        return
+    }
+
+    object.RestoreDays = request.Days
+    object.RestoreTier = request.GlacierJobParameters.Tier
+
+    // Restoring an object that is already available only extends how long it's kept.
+    if object.Restore != "" {
+        w.WriteHeader(http.StatusOK)
+        return
+    }
+
+    object.Restore = `ongoing-request="true"`
+    object.restoreRequestedAt = time.Now()
+    w.WriteHeader(http.StatusAccepted)
+}
+
+// Finishes a restore once RestoreDuration has passed since it was requested.
+func (s *Server) refreshRestore(object *Object) {
+    if s.RestoreDuration == 0 || object.Restore != `ongoing-request="true"` {
+        return
+    }
+
+    completedAt := object.restoreRequestedAt.Add(s.RestoreDuration)
+
+    if time.Now().Before(completedAt) {
+        return
+    }
+
+    expiryDate := completedAt.Add(time.Duration(object.RestoreDays) * 24 * time.Hour)
+    object.Restore = fmt.Sprintf(`ongoing-request="false", expiry-date="%s"`, expiryDate.UTC().Format(http.TimeFormat))
+}
+
+// Archived objects can only be read once a restored copy is available.
+func (s *Server) isReadable(object *Object) bool {
+    s.refreshRestore(object)
+
+    if class := storageClass(object); class != "GLACIER" && class != "DEEP_ARCHIVE" {
+        return true
+    }
+
+    return strings.HasPrefix(object.Restore, `ongoing-request="false"`)
+}
+
+// Finishes a restore directly, making the restored copy available until expiryDate.
+func (s *Server) CompleteRestore(bucketName, key string, expiryDate time.Time) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if b, ok := s.buckets[bucketName]; ok {
+        if object, ok := b.objects[key]; ok {
+            object.Restore = fmt.Sprintf(`ongoing-request="false", expiry-date="%s"`, expiryDate.UTC().Format(http.TimeFormat))
+        }
+    }
+}
+
+type initiateMultipartUploadResult struct {
+    XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+    Bucket   string
+    Key      string
+    UploadId string
+}
+
+func (s *Server) createMultipartUpload(w http.ResponseWriter, r *http.Request, b *bucket, bucketName, key string) {
+    s.nextUploadId++
+    uploadId := strconv.Itoa(s.nextUploadId)
+
+    b.uploads[uploadId] = &upload{key, storedHeaders(r.Header), make(map[int][]byte)}
+
+    writeXML(w, http.StatusOK, initiateMultipartUploadResult{Bucket: bucketName, Key: key, UploadId: uploadId})
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, b *bucket) {
+    query := r.URL.Query()
+    u, ok := b.uploads[query.Get("uploadId")]
+
+    if !ok {
+        writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.")
+        return
+    }
+
+    partNumber, err := strconv.Atoi(query.Get("partNumber"))
+
+    if err != nil {
+        writeError(w, http.StatusBadRequest, "InvalidArgument", "Invalid part number.")
+        return
+    }
+
+    if r.Header.Get("X-Amz-Copy-Source") != "" {
+        s.uploadPartCopy(w, r, u, partNumber)
+        return
+    }
+
+    body, err := ioutil.ReadAll(r.Body)
+
+    if err != nil {
+        writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+        return
+    }
+
+    u.parts[partNumber] = body
+    sum := md5.Sum(body)
+
+    w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+    w.WriteHeader(http.StatusOK)
+}
+
+type copyPartResult struct {
+    XMLName      xml.Name `xml:"CopyPartResult"`
+    LastModified string
+    ETag         string
+}
+
+// Handles UploadPartCopy, which fills a part with a range of an existing object.
+func (s *Server) uploadPartCopy(w http.ResponseWriter, r *http.Request, u *upload, partNumber int) {
+    source, err := s.copySource(r)
+
+    if err != nil {
+        writeError(w, http.StatusNotFound, "NoSuchKey", err.Error())
+        return
+    }
+
+    if !s.isReadable(source) {
+        writeError(w, http.StatusForbidden, "InvalidObjectState", "The source object of the COPY action is not in the active tier and is only stored in Amazon Glacier.")
+        return
+    }
+
+    if !hasCustomerKey(source, r.Header.Get("X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key-Md5")) {
+        writeError(w, http.StatusBadRequest, "InvalidRequest", "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.")
+        return
+    }
+
+    start, end := int64(0), int64(len(source.Body)) - 1
+
+    if header := r.Header.Get("X-Amz-Copy-Source-Range"); header != "" {
+        if start, end, err = parseRange(header, int64(len(source.Body))); err != nil {
+            writeError(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+            return
+        }
+    }
+
+    body := make([]byte, end - start + 1)
+    copy(body, source.Body[start:end+1])
+
+    u.parts[partNumber] = body
+    sum := md5.Sum(body)
+
+    writeXML(w, http.StatusOK, copyPartResult{LastModified: formatTime(time.Now()), ETag: `"` + hex.EncodeToString(sum[:]) + `"`})
+}
+
+type completeMultipartUploadRequest struct {
+    Parts []struct {
+        PartNumber int
+        ETag       string
+    } `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+    XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+    Bucket  string
+    Key     string
+    ETag    string
+}
+
+func (s *Server) completeMultipartUpload(w http.ResponseWriter, r *http.Request, b *bucket, bucketName, key string) {
+    uploadId := r.URL.Query().Get("uploadId")
+    u, ok := b.uploads[uploadId]
+
+    if !ok {
+        writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.")
+        return
+    }
+
+    request := completeMultipartUploadRequest{}
+
+    if err := readXML(r, &request); err != nil {
+        writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+        return
+    }
+
+    body := []byte{}
+    digests := md5.New()
+
+    for _, part := range request.Parts {
+        data, ok := u.parts[part.PartNumber]
+
+        if !ok {
+            writeError(w, http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found.")
+            return
+        }
+
+        sum := md5.Sum(data)
+        digests.Write(sum[:])
+        body = append(body, data...)
+    }
+
+    object := newObject(body, u.header)
+    object.ETag = fmt.Sprintf("%s-%d", hex.EncodeToString(digests.Sum(nil)), len(request.Parts))
+
+    setVersionHeader(w, b.put(key, object))
+    delete(b.uploads, uploadId)
+
+    writeXML(w, http.StatusOK, completeMultipartUploadResult{Bucket: bucketName, Key: key, ETag: `"` + object.ETag + `"`})
+}
+
+func (s *Server) abortMultipartUpload(w http.ResponseWriter, r *http.Request, b *bucket) {
+    delete(b.uploads, r.URL.Query().Get("uploadId"))
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// Starts a multipart upload directly, without going through the API. Returns its upload id.
+func (s *Server) CreateMultipartUpload(bucketName, key string) string {
+    s.CreateBucket(bucketName)
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.nextUploadId++
+    uploadId := strconv.Itoa(s.nextUploadId)
+
+    s.buckets[bucketName].uploads[uploadId] = &upload{key, http.Header{}, make(map[int][]byte)}
+    return uploadId
+}
+
+type uploadEntry struct {
+    Key      string
+    UploadId string
+}
+
+type listMultipartUploadsResult struct {
+    XMLName     xml.Name `xml:"ListMultipartUploadsResult"`
+    Bucket      string
+    IsTruncated bool
+    Uploads     []uploadEntry `xml:"Upload"`
+}
+
+// Lists every pending upload in one page, sorted by key and upload id.
+func (s *Server) listMultipartUploads(w http.ResponseWriter, bucketName string) {
+    b, ok := s.buckets[bucketName]
+
+    if !ok {
+        writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+        return
+    }
+
+    result := listMultipartUploadsResult{Bucket: bucketName}
+
+    for uploadId, u := range b.uploads {
+        result.Uploads = append(result.Uploads, uploadEntry{u.key, uploadId})
+    }
+
+    sort.Slice(result.Uploads, func(i, j int) bool {
+        if result.Uploads[i].Key != result.Uploads[j].Key {
+            return result.Uploads[i].Key < result.Uploads[j].Key
+        }
+        return result.Uploads[i].UploadId < result.Uploads[j].UploadId
+    })
+
+    writeXML(w, http.StatusOK, result)
+}
+
+// Pending multipart uploads across every bucket. Useful for checking that aborted uploads were cleaned up.
+func (s *Server) PendingUploads() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    count := 0
+
+    for _, b := range s.buckets {
+        count += len(b.uploads)
+    }
+
+    return count
+}
+
+// S3 only returns version ids for objects written while versioning was enabled.
+func setVersionHeader(w http.ResponseWriter, versionId string) {
+    if versionId != "" && versionId != nullVersionId {
+        w.Header().Set("X-Amz-Version-Id", versionId)
+    }
+}
+
+func formatTime(t time.Time) string {
+    return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
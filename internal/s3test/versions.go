@@ -0,0 +1,335 @@
+package s3test
+
+import (
+    "encoding/xml"
+    "fmt"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// The version id S3 gives objects written while versioning is off or suspended.
+const nullVersionId = "null"
+
+// Stores an object as the newest version of the key. Returns the version id it was given.
+func (b *bucket) put(key string, object *Object) string {
+    object.VersionId = b.newVersionId()
+    b.addVersion(key, object)
+
+    b.objects[key] = object
+    return object.VersionId
+}
+
+// Deletes the current version of a key the way S3 does: versioned buckets get a delete marker,
+// anything else loses the object for good. Returns the id of the delete marker, if one was added.
+func (b *bucket) remove(key string) string {
+    delete(b.objects, key)
+
+    if b.versioning == "" {
+        b.removeVersion(key, nullVersionId)
+        return ""
+    }
+
+    marker := &Object{DeleteMarker: true, LastModified: time.Now().UTC().Truncate(time.Second), Header: http.Header{}}
+    marker.VersionId = b.newVersionId()
+    b.addVersion(key, marker)
+
+    return marker.VersionId
+}
+
+// Permanently deletes a single version. Returns the version, or nil if it didn't exist.
+func (b *bucket) removeVersion(key, versionId string) *Object {
+    versions := b.versions[key]
+
+    for i, version := range versions {
+        if version.VersionId != versionId {
+            continue
+        }
+
+        versions = append(versions[:i:i], versions[i+1:]...)
+
+        if len(versions) == 0 {
+            delete(b.versions, key)
+        } else {
+            b.versions[key] = versions
+        }
+
+        b.refreshCurrent(key)
+        return version
+    }
+
+    return nil
+}
+
+// Looks up a version of a key. An empty version id means the current object.
+func (b *bucket) version(key, versionId string) (*Object, bool) {
+    if versionId == "" {
+        object, ok := b.objects[key]
+        return object, ok
+    }
+
+    for _, version := range b.versions[key] {
+        if version.VersionId == versionId {
+            return version, true
+        }
+    }
+
+    return nil, false
+}
+
+// Whether the bucket still holds anything, including old versions and delete markers.
+func (b *bucket) isEmpty() bool {
+    return len(b.objects) == 0 && len(b.versions) == 0
+}
+
+func (b *bucket) newVersionId() string {
+    if b.versioning != "Enabled" {
+        return nullVersionId
+    }
+
+    b.nextVersionId++
+
+    // Zero padded, so that ids sort in the order they were created.
+    return fmt.Sprintf("%016d", b.nextVersionId)
+}
+
+// Appends a version, replacing any existing null version since there can only be one.
+func (b *bucket) addVersion(key string, object *Object) {
+    if object.VersionId == nullVersionId {
+        for i, version := range b.versions[key] {
+            if version.VersionId == nullVersionId {
+                b.versions[key] = append(b.versions[key][:i:i], b.versions[key][i+1:]...)
+                break
+            }
+        }
+    }
+
+    b.versions[key] = append(b.versions[key], object)
+}
+
+// The current object is the newest version, unless that is a delete marker.
+func (b *bucket) refreshCurrent(key string) {
+    versions := b.versions[key]
+
+    if len(versions) == 0 || versions[len(versions)-1].DeleteMarker {
+        delete(b.objects, key)
+        return
+    }
+
+    b.objects[key] = versions[len(versions)-1]
+}
+
+// Turns on versioning for a bucket directly, without going through the API.
+func (s *Server) EnableVersioning(bucketName string) {
+    s.CreateBucket(bucketName)
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.buckets[bucketName].versioning = "Enabled"
+}
+
+// Every version of the key, including delete markers, oldest first.
+func (s *Server) Versions(bucketName, key string) []*Object {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if b, ok := s.buckets[bucketName]; ok {
+        return append([]*Object{}, b.versions[key]...)
+    }
+    return nil
+}
+
+// Deletes the current version of a key directly, leaving a delete marker in versioned buckets.
+func (s *Server) DeleteObject(bucketName, key string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if b, ok := s.buckets[bucketName]; ok {
+        b.remove(key)
+    }
+}
+
+type versioningConfiguration struct {
+    XMLName xml.Name `xml:"VersioningConfiguration"`
+    Status  string   `xml:",omitempty"`
+}
+
+func (s *Server) getBucketVersioning(w http.ResponseWriter, b *bucket) {
+    writeXML(w, http.StatusOK, versioningConfiguration{Status: b.versioning})
+}
+
+func (s *Server) putBucketVersioning(w http.ResponseWriter, r *http.Request, b *bucket) {
+    config := versioningConfiguration{}
+
+    if err := readXML(r, &config); err != nil {
+        writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+        return
+    }
+
+    if config.Status != "Enabled" && config.Status != "Suspended" {
+        writeError(w, http.StatusBadRequest, "IllegalVersioningConfigurationException", "The versioning configuration specified in the request is invalid.")
+        return
+    }
+
+    b.versioning = config.Status
+    w.WriteHeader(http.StatusOK)
+}
+
+type versionEntry struct {
+    Key          string
+    VersionId    string
+    IsLatest     bool
+    LastModified string
+    ETag         string
+    Size         int64
+    StorageClass string
+}
+
+type deleteMarkerEntry struct {
+    Key          string
+    VersionId    string
+    IsLatest     bool
+    LastModified string
+}
+
+type listVersionsResult struct {
+    XMLName             xml.Name `xml:"ListVersionsResult"`
+    Name                string
+    Prefix              string
+    Delimiter           string `xml:",omitempty"`
+    KeyMarker           string
+    VersionIdMarker     string
+    NextKeyMarker       string `xml:",omitempty"`
+    NextVersionIdMarker string `xml:",omitempty"`
+    MaxKeys             int
+    IsTruncated         bool
+    Versions            []versionEntry      `xml:"Version"`
+    DeleteMarkers       []deleteMarkerEntry `xml:"DeleteMarker"`
+    CommonPrefixes      []prefixEntry
+}
+
+// Lists every version of every key, newest first within a key, like ListObjectVersions.
+func (s *Server) listObjectVersions(w http.ResponseWriter, r *http.Request, bucketName string, b *bucket) {
+    if s.unlistable[bucketName] {
+        writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied")
+        return
+    }
+
+    query := r.URL.Query()
+    prefix := query.Get("prefix")
+    delimiter := query.Get("delimiter")
+    keyMarker := query.Get("key-marker")
+    versionIdMarker := query.Get("version-id-marker")
+
+    maxKeys := 1000
+    if v, err := strconv.Atoi(query.Get("max-keys")); err == nil && v > 0 {
+        maxKeys = v
+    }
+
+    keys := make([]string, 0, len(b.versions))
+    for key := range b.versions {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    result := listVersionsResult{
+        Name:            bucketName,
+        Prefix:          prefix,
+        Delimiter:       delimiter,
+        KeyMarker:       keyMarker,
+        VersionIdMarker: versionIdMarker,
+        MaxKeys:         maxKeys,
+    }
+
+    seenPrefixes := make(map[string]bool)
+    count := 0
+
+    for _, key := range keys {
+        if !strings.HasPrefix(key, prefix) || key < keyMarker || (key == keyMarker && versionIdMarker == "") {
+            continue
+        }
+
+        if delimiter != "" {
+            if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+                commonPrefix := key[:len(prefix)+i+len(delimiter)]
+
+                if !seenPrefixes[commonPrefix] {
+                    seenPrefixes[commonPrefix] = true
+                    result.CommonPrefixes = append(result.CommonPrefixes, prefixEntry{commonPrefix})
+                }
+                continue
+            }
+        }
+
+        versions := b.versions[key]
+
+        // Resuming part way through a key skips the versions up to and including the marker.
+        skipping := key == keyMarker
+
+        for i := len(versions) - 1; i >= 0; i-- {
+            version := versions[i]
+
+            if skipping {
+                skipping = version.VersionId != versionIdMarker
+                continue
+            }
+
+            if count >= maxKeys {
+                result.IsTruncated = true
+                writeXML(w, http.StatusOK, result)
+                return
+            }
+
+            isLatest := i == len(versions)-1
+            lastModified := formatTime(version.LastModified)
+
+            if version.DeleteMarker {
+                result.DeleteMarkers = append(result.DeleteMarkers, deleteMarkerEntry{key, version.VersionId, isLatest, lastModified})
+            } else {
+                result.Versions = append(result.Versions, versionEntry{key, version.VersionId, isLatest, lastModified, `"` + version.ETag + `"`, int64(len(version.Body)), storageClass(version)})
+            }
+
+            count++
+            result.NextKeyMarker = key
+            result.NextVersionIdMarker = version.VersionId
+        }
+    }
+
+    result.NextKeyMarker = ""
+    result.NextVersionIdMarker = ""
+    writeXML(w, http.StatusOK, result)
+}
+
+// Handles DeleteObject, with or without a version id.
+func (s *Server) deleteObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+    versionId := r.URL.Query().Get("versionId")
+
+    if versionId == "" {
+        if markerId := b.remove(key); markerId != "" {
+            w.Header().Set("X-Amz-Delete-Marker", "true")
+            w.Header().Set("X-Amz-Version-Id", markerId)
+        }
+        w.WriteHeader(http.StatusNoContent)
+        return
+    }
+
+    if version := b.removeVersion(key, versionId); version != nil {
+        w.Header().Set("X-Amz-Version-Id", versionId)
+
+        if version.DeleteMarker {
+            w.Header().Set("X-Amz-Delete-Marker", "true")
+        }
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func storageClass(object *Object) string {
+    if class := object.Header.Get("X-Amz-Storage-Class"); class != "" {
+        return class
+    }
+    return "STANDARD"
+}
@@ -0,0 +1,193 @@
+package s3go
+
+import (
+    "crypto/hmac"
+    "crypto/sha1"
+    "encoding/base64"
+    "net/http"
+    "net/url"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/aws/aws-sdk-go/aws/awsutil"
+    "github.com/aws/aws-sdk-go/aws/credentials"
+    "github.com/aws/aws-sdk-go/aws/request"
+    "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// Query parameters that are part of the resource being signed with signature version 2.
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/RESTAuthentication.html
+var signV2SubResources = map[string]bool{
+    "acl":                          true,
+    "cors":                         true,
+    "delete":                       true,
+    "lifecycle":                    true,
+    "location":                     true,
+    "logging":                      true,
+    "notification":                 true,
+    "partNumber":                   true,
+    "policy":                       true,
+    "replication":                  true,
+    "requestPayment":               true,
+    "response-cache-control":       true,
+    "response-content-disposition": true,
+    "response-content-encoding":    true,
+    "response-content-language":    true,
+    "response-content-type":        true,
+    "response-expires":             true,
+    "restore":                      true,
+    "tagging":                      true,
+    "torrent":                      true,
+    "uploadId":                     true,
+    "uploads":                      true,
+    "versionId":                    true,
+    "versioning":                   true,
+    "versions":                     true,
+    "website":                      true,
+}
+
+// Signs S3 requests with the legacy signature version 2, which some S3 compatible systems
+// still require. Takes the place of the SDK's v4 signer.
+var signV2RequestHandler = request.NamedHandler{
+    Name: "s3go.SignV2RequestHandler",
+    Fn:   signV2Request,
+}
+
+func useSignatureV2(handlers *request.Handlers) {
+    handlers.Sign.Swap(v4.SignRequestHandler.Name, signV2RequestHandler)
+}
+
+func signV2Request(r *request.Request) {
+    if r.Config.Credentials == credentials.AnonymousCredentials {
+        return
+    }
+
+    creds, err := r.Config.Credentials.Get()
+
+    if err != nil {
+        r.Error = err
+        return
+    }
+
+    req := r.HTTPRequest
+    presign := r.ExpireTime > 0
+
+    // Presigned URLs carry the expiry time where a signed request carries its date.
+    date := ""
+    if presign {
+        date = strconv.FormatInt(r.Time.Add(r.ExpireTime).Unix(), 10)
+    } else {
+        // Signed each attempt, so retries don't go out with a stale date.
+        date = time.Now().UTC().Format(http.TimeFormat)
+        req.Header.Set("Date", date)
+        req.Header.Del("X-Amz-Date")
+    }
+
+    if creds.SessionToken != "" {
+        req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+    }
+
+    signature := signV2(creds.SecretAccessKey, stringToSignV2(req, date, signV2Resource(r)))
+
+    if presign {
+        query := req.URL.Query()
+        query.Set("AWSAccessKeyId", creds.AccessKeyID)
+        query.Set("Expires", date)
+        query.Set("Signature", signature)
+
+        if creds.SessionToken != "" {
+            query.Set("x-amz-security-token", creds.SessionToken)
+            req.Header.Del("X-Amz-Security-Token")
+        }
+
+        req.URL.RawQuery = query.Encode()
+        return
+    }
+
+    req.Header.Set("Authorization", "AWS "+creds.AccessKeyID+":"+signature)
+}
+
+// The resource a v2 signature covers: the bucket and key, plus any sub-resources in the query.
+func signV2Resource(r *request.Request) string {
+    req := r.HTTPRequest
+    resource := req.URL.EscapedPath()
+
+    // With virtual-hosted addressing the bucket is in the host, but it is still signed as a path.
+    if values, _ := awsutil.ValuesAtPath(r.Params, "Bucket"); len(values) > 0 {
+        if bucket, ok := values[0].(*string); ok && bucket != nil && strings.HasPrefix(req.URL.Host, *bucket+".") {
+            resource = "/" + *bucket + resource
+        }
+    }
+
+    return resource + canonicalSubResources(req.URL.Query())
+}
+
+func canonicalSubResources(query url.Values) string {
+    keys := []string{}
+
+    for key := range query {
+        if signV2SubResources[key] {
+            keys = append(keys, key)
+        }
+    }
+
+    if len(keys) == 0 {
+        return ""
+    }
+
+    sort.Strings(keys)
+
+    parts := make([]string, len(keys))
+    for i, key := range keys {
+        if value := query.Get(key); value != "" {
+            parts[i] = key + "=" + value
+        } else {
+            parts[i] = key
+        }
+    }
+
+    return "?" + strings.Join(parts, "&")
+}
+
+func stringToSignV2(req *http.Request, date, resource string) string {
+    amzHeaders := []string{}
+
+    for name, values := range req.Header {
+        name = strings.ToLower(name)
+
+        if !strings.HasPrefix(name, "x-amz-") {
+            continue
+        }
+
+        trimmed := make([]string, len(values))
+        for i, value := range values {
+            trimmed[i] = strings.TrimSpace(value)
+        }
+
+        amzHeaders = append(amzHeaders, name+":"+strings.Join(trimmed, ","))
+    }
+
+    sort.Strings(amzHeaders)
+
+    canonicalHeaders := ""
+    for _, header := range amzHeaders {
+        canonicalHeaders += header + "\n"
+    }
+
+    return strings.Join([]string{
+        req.Method,
+        req.Header.Get("Content-MD5"),
+        req.Header.Get("Content-Type"),
+        date,
+    }, "\n") + "\n" + canonicalHeaders + resource
+}
+
+func signV2(secretKey, stringToSign string) string {
+    mac := hmac.New(sha1.New, []byte(secretKey))
+    mac.Write([]byte(stringToSign))
+
+    return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
@@ -0,0 +1,37 @@
+package s3go
+
+import (
+    "net/http"
+    "net/url"
+    "testing"
+)
+
+// Example from https://docs.aws.amazon.com/AmazonS3/latest/dev/RESTAuthentication.html
+func TestSignV2(t *testing.T) {
+    req, _ := http.NewRequest("GET", "https://s3.amazonaws.com/johnsmith/photos/puppy.jpg", nil)
+
+    date := "Tue, 27 Mar 2007 19:36:42 +0000"
+    stringToSign := stringToSignV2(req, date, "/johnsmith/photos/puppy.jpg")
+
+    if stringToSign != "GET\n\n\n"+date+"\n/johnsmith/photos/puppy.jpg" {
+        t.Errorf("unexpected string to sign %q", stringToSign)
+    }
+
+    signature := signV2("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", stringToSign)
+
+    if signature != "bWq2s1WEIj+Ydj0vQ697zp+IXMU=" {
+        t.Errorf("got %s, want bWq2s1WEIj+Ydj0vQ697zp+IXMU=", signature)
+    }
+}
+
+func TestCanonicalSubResources(t *testing.T) {
+    query, _ := url.ParseQuery("uploadId=abc&partNumber=2&prefix=logs%2F&acl")
+
+    if got := canonicalSubResources(query); got != "?acl&partNumber=2&uploadId=abc" {
+        t.Errorf("got %s", got)
+    }
+
+    if got := canonicalSubResources(url.Values{"list-type": {"2"}}); got != "" {
+        t.Errorf("got %s, want no sub-resources", got)
+    }
+}
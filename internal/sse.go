@@ -0,0 +1,134 @@
+package s3go
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "strings"
+
+    "github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+    SSE_AES256 = "AES256"
+    SSE_KMS    = "aws:kms"
+
+    // The only algorithm S3 accepts for customer provided keys
+    SSE_C_ALGORITHM = "AES256"
+
+    // Customer provided keys are 256 bits
+    SSE_C_KEY_SIZE = 32
+)
+
+// Server-side encryption for the objects a command writes, and the customer keys needed to read
+// objects that were encrypted with SSE-C. The zero value leaves everything to the bucket's defaults.
+type EncryptionOptions struct {
+    // Encryption for new objects: AES256 (SSE-S3) or aws:kms (SSE-KMS)
+    SSE string
+
+    // KMS key to encrypt new objects with. Defaults to the account's AWS managed key.
+    KMSKeyId string
+
+    // Encryption context for SSE-KMS, as a JSON object
+    KMSEncryptionContext string
+
+    // Customer provided key to encrypt new objects with (SSE-C)
+    CustomerKey []byte
+
+    // Customer provided key that the objects being read, downloaded or copied were encrypted with
+    SourceCustomerKey []byte
+}
+
+func ValidateEncryptionOptions(options EncryptionOptions) error {
+    switch options.SSE {
+    case "", SSE_AES256, SSE_KMS:
+    default:
+        return fmt.Errorf("Unknown --sse value %q. Expected one of: %s, %s", options.SSE, SSE_AES256, SSE_KMS)
+    }
+
+    if (options.KMSKeyId != "" || options.KMSEncryptionContext != "") && options.SSE != SSE_KMS {
+        return errors.New("--sse-kms-key-id and --sse-kms-context can only be used with --sse aws:kms.")
+    }
+
+    if options.KMSEncryptionContext != "" {
+        var context map[string]string
+
+        if err := json.Unmarshal([]byte(options.KMSEncryptionContext), &context); err != nil {
+            return fmt.Errorf("--sse-kms-context must be a JSON object of strings: %v", err)
+        }
+    }
+
+    if options.SSE != "" && len(options.CustomerKey) > 0 {
+        return errors.New("--sse and --sse-c can't be used together.")
+    }
+
+    return nil
+}
+
+// Reads a customer provided key from "file:<path>" or "env:<name>". The key can be stored as
+// the raw 32 bytes or base64 encoded.
+func LoadCustomerKey(source string) ([]byte, error) {
+    var value []byte
+
+    switch {
+    case strings.HasPrefix(source, "file:"):
+        body, err := ioutil.ReadFile(strings.TrimPrefix(source, "file:"))
+
+        if err != nil {
+            return nil, err
+        }
+
+        value = body
+    case strings.HasPrefix(source, "env:"):
+        name := strings.TrimPrefix(source, "env:")
+        env, ok := os.LookupEnv(name)
+
+        if !ok {
+            return nil, fmt.Errorf("The environment variable %s isn't set.", name)
+        }
+
+        value = []byte(env)
+    default:
+        return nil, fmt.Errorf("Expected a customer key as file:<path> or env:<name>, got %q.", source)
+    }
+
+    if len(value) == SSE_C_KEY_SIZE {
+        return value, nil
+    }
+
+    key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(value)))
+
+    if err != nil || len(key) != SSE_C_KEY_SIZE {
+        return nil, fmt.Errorf("Customer keys must be %d bytes, either raw or base64 encoded.", SSE_C_KEY_SIZE)
+    }
+
+    return key, nil
+}
+
+func(e EncryptionOptions) serverSideEncryption() *string {
+    return nonEmptyString(e.SSE)
+}
+
+func(e EncryptionOptions) kmsKeyId() *string {
+    return nonEmptyString(e.KMSKeyId)
+}
+
+// S3 expects the encryption context base64 encoded.
+func(e EncryptionOptions) kmsEncryptionContext() *string {
+    if e.KMSEncryptionContext == "" {
+        return nil
+    }
+    return aws.String(base64.StdEncoding.EncodeToString([]byte(e.KMSEncryptionContext)))
+}
+
+// The algorithm and key parameters for an SSE-C key, both nil without one. The SDK takes care of
+// encoding the key and adding its MD5.
+func customerKeyParams(key []byte) (*string, *string) {
+    if len(key) == 0 {
+        return nil, nil
+    }
+    return aws.String(SSE_C_ALGORITHM), aws.String(string(key))
+}
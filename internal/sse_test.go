@@ -0,0 +1,62 @@
+package s3go
+
+import (
+    "encoding/base64"
+    "os"
+    "testing"
+)
+
+func TestValidateEncryptionOptions(t *testing.T) {
+    var tests = []struct {
+        name    string
+        options EncryptionOptions
+        wantErr bool
+    }{
+        {"none", EncryptionOptions{}, false},
+        {"sse-s3", EncryptionOptions{SSE: SSE_AES256}, false},
+        {"sse-kms", EncryptionOptions{SSE: SSE_KMS, KMSKeyId: "alias/key", KMSEncryptionContext: `{"a":"b"}`}, false},
+        {"sse-c", EncryptionOptions{CustomerKey: make([]byte, SSE_C_KEY_SIZE)}, false},
+        {"unknown", EncryptionOptions{SSE: "rot13"}, true},
+        {"key without kms", EncryptionOptions{SSE: SSE_AES256, KMSKeyId: "alias/key"}, true},
+        {"invalid context", EncryptionOptions{SSE: SSE_KMS, KMSEncryptionContext: "team=data"}, true},
+        {"sse and sse-c", EncryptionOptions{SSE: SSE_AES256, CustomerKey: make([]byte, SSE_C_KEY_SIZE)}, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := ValidateEncryptionOptions(tt.options)
+            if (err != nil) != tt.wantErr {
+                t.Errorf("got %v, want error: %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestLoadCustomerKey(t *testing.T) {
+    raw := "0123456789abcdef0123456789abcdef"
+
+    os.Setenv("S3GO_TEST_SSE_C_KEY", base64.StdEncoding.EncodeToString([]byte(raw)) + "\n")
+    defer os.Unsetenv("S3GO_TEST_SSE_C_KEY")
+
+    if key, err := LoadCustomerKey("env:S3GO_TEST_SSE_C_KEY"); err != nil || string(key) != raw {
+        t.Errorf("got %q (%v), want the decoded key", key, err)
+    }
+
+    os.Setenv("S3GO_TEST_SSE_C_KEY", raw)
+
+    if key, err := LoadCustomerKey("env:S3GO_TEST_SSE_C_KEY"); err != nil || string(key) != raw {
+        t.Errorf("got %q (%v), want the raw key", key, err)
+    }
+
+    for _, source := range []string{"env:S3GO_TEST_MISSING", "file:/does/not/exist", raw} {
+        if _, err := LoadCustomerKey(source); err == nil {
+            t.Errorf("expected an error for %s", source)
+        }
+    }
+
+    os.Setenv("S3GO_TEST_SSE_C_KEY", "too short")
+
+    if _, err := LoadCustomerKey("env:S3GO_TEST_SSE_C_KEY"); err == nil {
+        t.Errorf("expected an error for a key of the wrong size")
+    }
+}
@@ -0,0 +1,200 @@
+package s3go
+
+import (
+    "context"
+    "path/filepath"
+    "sort"
+    "time"
+)
+
+type ListSyncObjectsInput struct {
+    // Object representation of the source path
+    SourceUrl *S3Url
+
+    // Object representation of the destination path
+    TargetUrl *S3Url
+
+    // List destination objects that don't exist in the source so they can be deleted
+    Delete bool
+
+    // How source objects are compared against existing destination objects
+    CompareMode CompareMode
+
+    // --include and --exclude rules that decide which files or objects are kept
+    Filters Filters
+}
+
+type ListSyncObjectsOutput struct {
+    // Source objects that are missing or out of date at the destination
+    Transfers <-chan ObjectInfo
+
+    // Destination objects that have no counterpart in the source. Only populated once
+    // Transfers has been drained, and only when ListSyncObjectsInput.Delete is set.
+    Deletes <-chan ObjectInfo
+}
+
+// List source objects that are missing or out of date at the destination. Used for the "sync" command.
+func(c *Client) ListSyncObjects(ctx context.Context, options *ListSyncObjectsInput) (*ListSyncObjectsOutput, error) {
+    // Target keys are matched relative to the target, the way source keys are to the source.
+    targetRoot := options.TargetUrl.Prefix
+    if !options.TargetUrl.IsLocal() {
+        targetRoot = directoryPrefix(targetRoot)
+    }
+
+    targetFilter, err := newFilterMatcher(options.Filters, targetRoot, options.TargetUrl.IsLocal())
+    if err != nil {
+        return nil, err
+    }
+
+    targetObjects, err := c.listTargetObjects(ctx, options.TargetUrl)
+
+    if err != nil {
+        return nil, err
+    }
+
+    // S3 prefixes are listed as directories, so "data" doesn't pick up "database/x.txt".
+    sourceUrl := options.SourceUrl
+    if !sourceUrl.IsLocal() {
+        sourceUrl = &S3Url{sourceUrl.Scheme, sourceUrl.Bucket, directoryPrefix(sourceUrl.Prefix)}
+    }
+
+    sourceCh, err := c.ListSourceObjects(ctx, &ListSourceObjectsInput{
+        SourceUrl: sourceUrl,
+        Recursive: true,
+        Filters:   options.Filters,
+    })
+
+    if err != nil {
+        return nil, err
+    }
+
+    transferCh := make(chan ObjectInfo)
+    deleteCh := make(chan ObjectInfo)
+
+    go func() {
+        defer close(deleteCh)
+
+        seenKeys := make(map[string]bool)
+        listed := true
+
+        for object := range sourceCh {
+            // The failure is reported like a failed transfer. Without the full source listing,
+            // nothing at the destination can safely be deleted.
+            if object.Err != nil {
+                listed = false
+
+                if !sendObject(ctx, transferCh, object) {
+                    break
+                }
+                continue
+            }
+
+            if object.IsPrefix {
+                continue
+            }
+
+            targetKey := syncKey(options.TargetUrl, resolveTargetKey(object, options.SourceUrl, options.TargetUrl, true))
+            targetObject, ok := targetObjects[targetKey]
+            seenKeys[targetKey] = true
+
+            if ok {
+                // If the comparison itself fails, transferring again is the safe choice.
+                modified, err := c.IsObjectModified(object, targetObject, options.CompareMode)
+
+                if err == nil && !modified {
+                    continue
+                }
+            }
+
+            if !sendObject(ctx, transferCh, object) {
+                break
+            }
+        }
+
+        close(transferCh)
+
+        if !options.Delete || !listed || ctx.Err() != nil {
+            return
+        }
+
+        targetKeys := make([]string, 0, len(targetObjects))
+
+        for key := range targetObjects {
+            targetKeys = append(targetKeys, key)
+        }
+
+        sort.Strings(targetKeys)
+
+        for _, key := range targetKeys {
+            // Excluded objects are never deleted, even if they are missing from the source.
+            if seenKeys[key] || !targetFilter.includes(key) {
+                continue
+            }
+            if !sendObject(ctx, deleteCh, targetObjects[key]) {
+                return
+            }
+        }
+    }()
+
+    return &ListSyncObjectsOutput{transferCh, deleteCh}, nil
+}
+
+// Lists everything under the destination path, keyed so it can be matched against source objects.
+func(c *Client) listTargetObjects(ctx context.Context, targetUrl *S3Url) (map[string]ObjectInfo, error) {
+    targetObjects := make(map[string]ObjectInfo)
+
+    // A local destination that doesn't exist yet simply has nothing in it.
+    if targetUrl.IsLocal() && pathExists(targetUrl.Prefix) != nil {
+        return targetObjects, nil
+    }
+
+    // Siblings such as "backup-2019/" are never part of a "backup" destination.
+    prefix := targetUrl.Prefix
+    if !targetUrl.IsLocal() {
+        prefix = directoryPrefix(prefix)
+    }
+
+    objectCh, err := listFrom(ctx, c.Backend(targetUrl), &ListObjectsV2Input{
+        Bucket:    targetUrl.Bucket,
+        Prefix:    prefix,
+        Recursive: true,
+    })
+
+    if err != nil {
+        return nil, err
+    }
+
+    for object := range objectCh {
+        if object.Err != nil {
+            return nil, object.Err
+        }
+        if object.IsPrefix {
+            continue
+        }
+        targetObjects[syncKey(targetUrl, *object.Key)] = object
+    }
+
+    return targetObjects, nil
+}
+
+// Local paths are cleaned so "./dir/file" and "dir/file" are treated as the same file.
+func syncKey(url *S3Url, key string) string {
+    if url.IsLocal() {
+        return filepath.Clean(key)
+    }
+    return key
+}
+
+// An object needs to be synced when its size differs or the source is newer than the destination.
+func isObjectModified(source, target ObjectInfo) bool {
+    if source.Size == nil || target.Size == nil || *source.Size != *target.Size {
+        return true
+    }
+
+    if source.LastModified == nil || target.LastModified == nil {
+        return true
+    }
+
+    // S3 only stores timestamps to the second, so finer differences are ignored.
+    return source.LastModified.Truncate(time.Second).After(target.LastModified.Truncate(time.Second))
+}
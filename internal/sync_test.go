@@ -0,0 +1,206 @@
+package s3go
+
+import (
+    "context"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/scruwys/s3go/internal/s3test"
+)
+
+func TestIsObjectModifiedBySizeAndTime(t *testing.T) {
+    older := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+    newer := older.Add(time.Hour)
+
+    var tests = []struct {
+        name string
+        sourceSize, targetSize int64
+        sourceTime, targetTime time.Time
+        want bool
+    }{
+        {"unchanged", 10, 10, older, older, false},
+        {"target is newer", 10, 10, older, newer, false},
+        {"source is newer", 10, 10, newer, older, true},
+        {"size differs", 10, 11, older, newer, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            source := ObjectInfo{Size: &tt.sourceSize, LastModified: &tt.sourceTime}
+            target := ObjectInfo{Size: &tt.targetSize, LastModified: &tt.targetTime}
+
+            ans := isObjectModified(source, target)
+            if ans != tt.want {
+                t.Errorf("got %v, want %v", ans, tt.want)
+            }
+        })
+    }
+}
+
+func TestSyncKey(t *testing.T) {
+    local := &S3Url{"", " ", "./backup/"}
+    remote := &S3Url{"s3", "s3go-testing", "backup/"}
+
+    if key := syncKey(local, "./backup/a/../b.txt"); key != "backup/b.txt" {
+        t.Errorf("got %s, want backup/b.txt", key)
+    }
+
+    if key := syncKey(remote, "backup/./b.txt"); key != "backup/./b.txt" {
+        t.Errorf("S3 keys should not be cleaned, got %s", key)
+    }
+}
+
+func TestListSyncObjectsWithDelete(t *testing.T) {
+    root, err := ioutil.TempDir("", "s3go-sync")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(root)
+
+    sourceDir := filepath.Join(root, "source")
+    targetDir := filepath.Join(root, "target")
+
+    writeTestFile(t, filepath.Join(sourceDir, "new.txt"), "new")
+    writeTestFile(t, filepath.Join(sourceDir, "same.txt"), "same")
+    writeTestFile(t, filepath.Join(targetDir, "same.txt"), "same")
+    writeTestFile(t, filepath.Join(targetDir, "stale.txt"), "stale")
+    writeTestFile(t, filepath.Join(targetDir, "keep.log"), "keep")
+
+    // The destination copy was written after the source, so it is up to date.
+    future := time.Now().Add(time.Hour)
+    os.Chtimes(filepath.Join(targetDir, "same.txt"), future, future)
+
+    client := NewClient(&ClientOptions{})
+    output, err := client.ListSyncObjects(context.Background(), &ListSyncObjectsInput{
+        SourceUrl: &S3Url{"", " ", sourceDir},
+        TargetUrl: &S3Url{"", " ", targetDir},
+        Delete:    true,
+        Filters:   Filters{Rules: []FilterRule{{Exclude: true, Pattern: "*.log"}}},
+    })
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var transfers, deletes []string
+
+    for object := range output.Transfers {
+        transfers = append(transfers, filepath.Base(*object.Key))
+    }
+
+    for object := range output.Deletes {
+        deletes = append(deletes, filepath.Base(*object.Key))
+    }
+
+    if len(transfers) != 1 || transfers[0] != "new.txt" {
+        t.Errorf("got transfers %v, want [new.txt]", transfers)
+    }
+
+    if len(deletes) != 1 || deletes[0] != "stale.txt" {
+        t.Errorf("got deletes %v, want [stale.txt]", deletes)
+    }
+
+    // Keys that only share the start of an S3 prefix belong to sibling directories.
+    server := s3test.NewServer()
+    defer server.Close()
+    defer useTestServer(t, server)()
+
+    server.CreateBucket("s3go-sync")
+    server.PutObject("s3go-sync", "data/new.txt", []byte("new"))
+    server.PutObject("s3go-sync", "database/x.txt", []byte("x"))
+    server.PutObject("s3go-sync", "backup/stale.txt", []byte("stale"))
+    server.PutObject("s3go-sync", "backup-2019/keep.txt", []byte("keep"))
+    server.PutObject("s3go-sync", "backups.txt", []byte("keep"))
+
+    client = NewClient(&ClientOptions{Endpoint: server.URL, Region: "us-east-1", DisableSSL: true})
+    output, err = client.ListSyncObjects(context.Background(), &ListSyncObjectsInput{
+        SourceUrl: &S3Url{"s3", "s3go-sync", "data"},
+        TargetUrl: &S3Url{"s3", "s3go-sync", "backup"},
+        Delete:    true,
+    })
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    transfers, deletes = nil, nil
+
+    for object := range output.Transfers {
+        transfers = append(transfers, *object.Key)
+    }
+
+    for object := range output.Deletes {
+        deletes = append(deletes, *object.Key)
+    }
+
+    if len(transfers) != 1 || transfers[0] != "data/new.txt" {
+        t.Errorf("got transfers %v, want [data/new.txt]", transfers)
+    }
+
+    if len(deletes) != 1 || deletes[0] != "backup/stale.txt" {
+        t.Errorf("got deletes %v, want [backup/stale.txt]", deletes)
+    }
+}
+
+func TestListSyncObjectsSkipsDeleteWhenListingFails(t *testing.T) {
+    server := s3test.NewServer()
+    defer server.Close()
+    defer useTestServer(t, server)()
+
+    server.CreateBucket("s3go-sync")
+    server.PutObject("s3go-sync", "data/a.txt", []byte("a"))
+    server.DenyListing("s3go-sync")
+
+    targetDir, err := ioutil.TempDir("", "s3go-sync")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(targetDir)
+
+    writeTestFile(t, filepath.Join(targetDir, "a.txt"), "a")
+
+    client := NewClient(&ClientOptions{Endpoint: server.URL, Region: "us-east-1", DisableSSL: true})
+    output, err := client.ListSyncObjects(context.Background(), &ListSyncObjectsInput{
+        SourceUrl: &S3Url{"s3", "s3go-sync", "data/"},
+        TargetUrl: &S3Url{"", " ", targetDir},
+        Delete:    true,
+    })
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var failures, deletes int
+
+    for object := range output.Transfers {
+        if object.Err == nil {
+            t.Errorf("got transfer of %s, want only the failed listing", object.Url())
+        }
+        failures++
+    }
+
+    for range output.Deletes {
+        deletes++
+    }
+
+    if failures != 1 {
+        t.Errorf("got %d failed listings, want 1", failures)
+    }
+
+    if deletes != 0 {
+        t.Errorf("got %d deletes after the source listing failed, want none", deletes)
+    }
+}
+
+func writeTestFile(t *testing.T, path, body string) {
+    if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+        t.Fatal(err)
+    }
+
+    if err := ioutil.WriteFile(path, []byte(body), 0600); err != nil {
+        t.Fatal(err)
+    }
+}
@@ -0,0 +1,146 @@
+package s3go
+
+import (
+    "context"
+    "time"
+)
+
+// What has to change for a key to look the way it did at a point in time.
+type VersionRestore struct {
+    // The version that was current at that time, or the item a failed listing ended with
+    Version ObjectInfo
+
+    // Whether newer versions of the object were written since. If so, Version is copied back over them.
+    Overwritten bool
+
+    // Delete markers added since. When the object was only deleted, removing them makes Version current again.
+    DeleteMarkers []ObjectInfo
+}
+
+// Lists what has to change under a prefix to bring it back to how it was at asOf. Keys that haven't
+// changed since, didn't exist yet or had already been deleted at the time are left out.
+func(c *Client) ListVersionRestores(ctx context.Context, options *ListObjectsV2Input, asOf time.Time) (<-chan VersionRestore, error) {
+    versionCh, err := c.ListObjectVersions(ctx, options)
+
+    if err != nil {
+        return nil, err
+    }
+
+    outputCh := make(chan VersionRestore)
+
+    go func() {
+        defer close(outputCh)
+
+        // Versions of a key arrive together, newest first, even when they span several pages.
+        versions := []ObjectInfo{}
+
+        flush := func() bool {
+            restore, ok := planVersionRestore(versions, asOf)
+            versions = versions[:0]
+
+            if !ok {
+                return true
+            }
+
+            select {
+                case outputCh <- restore:
+                    return true
+                case <-ctx.Done():
+                    return false
+            }
+        }
+
+        for version := range versionCh {
+            // A failed listing is passed on as the version of a restore of its own.
+            if version.Err != nil {
+                if len(versions) > 0 && !flush() {
+                    return
+                }
+
+                select {
+                    case outputCh <- VersionRestore{Version: version}:
+                    case <-ctx.Done():
+                }
+                return
+            }
+
+            if version.IsPrefix {
+                continue
+            }
+
+            if len(versions) > 0 && *versions[0].Key != *version.Key && !flush() {
+                return
+            }
+
+            versions = append(versions, version)
+        }
+
+        if len(versions) > 0 {
+            flush()
+        }
+    }()
+
+    return outputCh, nil
+}
+
+// Works out how to restore a single key from its versions, newest first.
+func planVersionRestore(versions []ObjectInfo, asOf time.Time) (VersionRestore, bool) {
+    for i, version := range versions {
+        if version.LastModified.After(asOf) {
+            continue
+        }
+
+        // Nothing to do if the key was already deleted at the time, or nothing has changed since.
+        if version.IsDeleteMarker || i == 0 {
+            return VersionRestore{}, false
+        }
+
+        restore := VersionRestore{Version: version}
+
+        for _, newer := range versions[:i] {
+            if newer.IsDeleteMarker {
+                restore.DeleteMarkers = append(restore.DeleteMarkers, newer)
+            } else {
+                restore.Overwritten = true
+            }
+        }
+
+        // Copying the version back makes it current regardless of any delete markers.
+        if restore.Overwritten {
+            restore.DeleteMarkers = nil
+        }
+
+        return restore, true
+    }
+
+    // The key didn't exist yet.
+    return VersionRestore{}, false
+}
+
+// Makes the version that was current at the point in time the current version again, either by
+// deleting the delete markers added since or by copying it back over newer versions. Copies keep the
+// version's storage class, headers, encryption and ACL. Versions encrypted with a customer key need
+// options.Encryption.SourceCustomerKey.
+func(c *Client) RestoreVersion(ctx context.Context, restore VersionRestore, options *MoveObjectOptions) (string, error) {
+    object := restore.Version
+    logMessage := object.Url() + " (version " + *object.VersionId + ")"
+
+    if options.DryRun {
+        return logMessage, nil
+    }
+
+    if restore.Overwritten {
+        if err := c.copyInPlace(ctx, object, options); err != nil {
+            return "", err
+        }
+        return logMessage, nil
+    }
+
+    for _, err := range c.RemoveObjects(ctx, restore.DeleteMarkers, "") {
+        if err != nil {
+            return "", err
+        }
+    }
+
+    return logMessage, nil
+}
@@ -0,0 +1,51 @@
+package s3go
+
+import (
+    "testing"
+    "time"
+
+    "github.com/aws/aws-sdk-go/aws"
+)
+
+func TestPlanVersionRestore(t *testing.T) {
+    asOf := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+    version := func(id string, hoursAfter int, deleteMarker bool) ObjectInfo {
+        lastModified := asOf.Add(time.Duration(hoursAfter) * time.Hour)
+        return ObjectInfo{Key: aws.String("a.txt"), VersionId: aws.String(id), LastModified: &lastModified, IsDeleteMarker: deleteMarker}
+    }
+
+    var tests = []struct {
+        name        string
+        versions    []ObjectInfo
+        ok          bool
+        want        string
+        overwritten bool
+        markers     int
+    }{
+        {"unchanged", []ObjectInfo{version("1", -1, false)}, false, "", false, 0},
+        {"created later", []ObjectInfo{version("1", 1, false)}, false, "", false, 0},
+        {"deleted at the time", []ObjectInfo{version("2", 1, false), version("1", -1, true)}, false, "", false, 0},
+        {"overwritten", []ObjectInfo{version("2", 1, false), version("1", -1, false)}, true, "1", true, 0},
+        {"deleted", []ObjectInfo{version("3", 2, true), version("2", 1, true), version("1", -1, false)}, true, "1", false, 2},
+        {"deleted and overwritten", []ObjectInfo{version("3", 2, false), version("2", 1, true), version("1", -1, false)}, true, "1", true, 0},
+        {"modified exactly at the time", []ObjectInfo{version("2", 0, false), version("1", -1, false)}, false, "", false, 0},
+    }
+
+    for _, tt := range tests {
+        restore, ok := planVersionRestore(tt.versions, asOf)
+
+        if ok != tt.ok {
+            t.Errorf("%s: got ok %v, want %v", tt.name, ok, tt.ok)
+            continue
+        }
+
+        if !ok {
+            continue
+        }
+
+        if *restore.Version.VersionId != tt.want || restore.Overwritten != tt.overwritten || len(restore.DeleteMarkers) != tt.markers {
+            t.Errorf("%s: got version %s, overwritten %v, %d delete markers", tt.name, *restore.Version.VersionId, restore.Overwritten, len(restore.DeleteMarkers))
+        }
+    }
+}